@@ -0,0 +1,178 @@
+package acoustid
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.senan.xyz/wrtag/clientutil"
+)
+
+// https://acoustid.org/webservice
+
+var ErrNoFpcalc = fmt.Errorf("fpcalc not found in PATH")
+
+const FpcalcCommand = "fpcalc"
+
+type Fingerprint struct {
+	Duration    int
+	Fingerprint string
+}
+
+// Calculate runs chromaprint's fpcalc on the file at path.
+func Calculate(ctx context.Context, path string) (fp Fingerprint, err error) {
+	if _, err := exec.LookPath(FpcalcCommand); err != nil {
+		return Fingerprint{}, fmt.Errorf("%w: %w", ErrNoFpcalc, err)
+	}
+
+	cmd := exec.CommandContext(ctx, FpcalcCommand, "-json", path)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	defer func() {
+		if err != nil && stderr.Len() > 0 {
+			err = fmt.Errorf("%w: stderr: %q", err, stderr.String())
+		}
+	}()
+
+	out, err := cmd.Output()
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("run cmd: %w", err)
+	}
+	return parseFpcalc(out)
+}
+
+// parseFpcalc parses the output of fpcalc -json.
+func parseFpcalc(out []byte) (Fingerprint, error) {
+	var res struct {
+		Duration    float64 `json:"duration"`
+		Fingerprint string  `json:"fingerprint"`
+	}
+	if err := json.Unmarshal(out, &res); err != nil {
+		return Fingerprint{}, fmt.Errorf("decode output: %w", err)
+	}
+	if res.Fingerprint == "" {
+		return Fingerprint{}, fmt.Errorf("empty fingerprint")
+	}
+	return Fingerprint{Duration: int(math.Round(res.Duration)), Fingerprint: res.Fingerprint}, nil
+}
+
+type Client struct {
+	BaseURL   string
+	APIKey    string
+	RateLimit time.Duration
+
+	initOnce   sync.Once
+	HTTPClient *http.Client
+}
+
+// Enabled reports whether the client has been configured with an API key.
+func (c *Client) Enabled() bool {
+	return c.APIKey != ""
+}
+
+func (c *Client) request(ctx context.Context, r *http.Request, dest any) error {
+	c.initOnce.Do(func() {
+		c.HTTPClient = clientutil.Wrap(c.HTTPClient, clientutil.Chain(
+			clientutil.WithRateLimit(c.RateLimit),
+		))
+	})
+
+	r = r.WithContext(ctx)
+	resp, err := c.HTTPClient.Do(r)
+	if err != nil {
+		return fmt.Errorf("make acoustid request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("acoustid returned non 2xx: %w", StatusError(resp.StatusCode))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("decode acoustid response: %w", err)
+	}
+	return nil
+}
+
+// Lookup finds the recordings matching a fingerprint, along with the IDs of the releases they appear on.
+func (c *Client) Lookup(ctx context.Context, fp Fingerprint) ([]Result, error) {
+	urlV := url.Values{}
+	urlV.Set("format", "json")
+	urlV.Set("client", c.APIKey)
+	urlV.Set("meta", "recordings releaseids")
+	urlV.Set("duration", strconv.Itoa(fp.Duration))
+	urlV.Set("fingerprint", fp.Fingerprint)
+
+	// fingerprints of long tracks are too big for a query string, so they're posted as a form
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, joinPath(c.BaseURL, "lookup"), strings.NewReader(urlV.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var resp struct {
+		Status string `json:"status"`
+		Error  struct {
+			Message string `json:"message"`
+		} `json:"error"`
+		Results []Result `json:"results"`
+	}
+	if err := c.request(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("request lookup: %w", err)
+	}
+	if resp.Status != "ok" {
+		return nil, fmt.Errorf("acoustid returned status %q: %s", resp.Status, resp.Error.Message)
+	}
+	return resp.Results, nil
+}
+
+type Result struct {
+	ID         string      `json:"id"`
+	Score      float64     `json:"score"`
+	Recordings []Recording `json:"recordings"`
+}
+
+type Recording struct {
+	ID       string `json:"id"`
+	Releases []struct {
+		ID string `json:"id"`
+	} `json:"releases"`
+}
+
+// ReleaseIDs returns the unique release IDs found in results.
+func ReleaseIDs(results []Result) []string {
+	var ids []string
+	seen := map[string]struct{}{}
+	for _, res := range results {
+		for _, rec := range res.Recordings {
+			for _, rel := range rec.Releases {
+				if _, ok := seen[rel.ID]; ok || rel.ID == "" {
+					continue
+				}
+				seen[rel.ID] = struct{}{}
+				ids = append(ids, rel.ID)
+			}
+		}
+	}
+	return ids
+}
+
+var _ error = StatusError(0)
+
+type StatusError int
+
+func (se StatusError) Error() string {
+	return strconv.Itoa(int(se))
+}
+
+func joinPath(base string, p ...string) string {
+	r, _ := url.JoinPath(base, p...)
+	return r
+}
@@ -0,0 +1,79 @@
+package acoustid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFpcalc(t *testing.T) {
+	t.Parallel()
+
+	fp, err := parseFpcalc([]byte(`{"duration": 202.46, "fingerprint": "AQADtEmUaEkSRZEG"}`))
+	require.NoError(t, err)
+	assert.Equal(t, Fingerprint{Duration: 202, Fingerprint: "AQADtEmUaEkSRZEG"}, fp)
+
+	_, err = parseFpcalc([]byte(`{"duration": 202.46, "fingerprint": ""}`))
+	assert.ErrorContains(t, err, "empty fingerprint")
+
+	_, err = parseFpcalc([]byte(`ERROR: Could not open the input file`))
+	assert.ErrorContains(t, err, "decode output")
+}
+
+func TestLookup(t *testing.T) {
+	t.Parallel()
+
+	fingerprint := strings.Repeat("AQADtEmUaEkSRZEG", 1024) // too long for most query strings
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/v2/lookup", r.URL.Path)
+		assert.Empty(t, r.URL.RawQuery)
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "abc", r.PostForm.Get("client"))
+		assert.Equal(t, "202", r.PostForm.Get("duration"))
+		assert.Equal(t, fingerprint, r.PostForm.Get("fingerprint"))
+		assert.Equal(t, "recordings releaseids", r.PostForm.Get("meta"))
+
+		w.Write([]byte(`{"status": "ok", "results": [
+			{"id": "a", "score": 0.9, "recordings": [{"id": "r1", "releases": [{"id": "rel1"}, {"id": "rel2"}]}]},
+			{"id": "b", "score": 0.5, "recordings": [{"id": "r2", "releases": [{"id": "rel2"}, {"id": ""}]}]}
+		]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := &Client{BaseURL: srv.URL + "/v2", APIKey: "abc"}
+	results, err := c.Lookup(context.Background(), Fingerprint{Duration: 202, Fingerprint: fingerprint})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 0.9, results[0].Score)
+	assert.Equal(t, []string{"rel1", "rel2"}, ReleaseIDs(results))
+}
+
+func TestLookupError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status/lookup":
+			w.Write([]byte(`{"status": "error", "error": {"code": 4, "message": "invalid API key"}}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c := &Client{BaseURL: srv.URL + "/status", APIKey: "abc"}
+	_, err := c.Lookup(context.Background(), Fingerprint{Duration: 1, Fingerprint: "x"})
+	assert.ErrorContains(t, err, `status "error": invalid API key`)
+
+	c = &Client{BaseURL: srv.URL + "/down", APIKey: "abc"}
+	_, err = c.Lookup(context.Background(), Fingerprint{Duration: 1, Fingerprint: "x"})
+	assert.ErrorIs(t, err, StatusError(http.StatusServiceUnavailable))
+}
@@ -0,0 +1,175 @@
+package embedcover
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.senan.xyz/wrtag/addon"
+	"go.senan.xyz/wrtag/coverparse"
+	"go.senan.xyz/wrtag/imageutil"
+	"go.senan.xyz/wrtag/tags"
+)
+
+func init() {
+	addon.Register("embedcover", NewEmbedCoverAddon)
+}
+
+type EmbedCoverAddon struct {
+	maxSize int  // bytes, cover is resized if larger, and skipped if still larger
+	resize  int  // pixels, cover is resized to fit if larger
+	keep    bool // keep existing pictures, instead of replacing them
+}
+
+func NewEmbedCoverAddon(conf string) (EmbedCoverAddon, error) {
+	var a EmbedCoverAddon
+	for _, arg := range strings.Fields(conf) {
+		k, v, _ := strings.Cut(arg, "=")
+		var err error
+		switch k {
+		case "max-size":
+			a.maxSize, err = parseSize(v)
+		case "resize":
+			a.resize, err = strconv.Atoi(v)
+		case "keep":
+			a.keep = true
+		case "replace":
+			a.keep = false
+		default:
+			return EmbedCoverAddon{}, fmt.Errorf("unknown option %q", arg)
+		}
+		if err != nil {
+			return EmbedCoverAddon{}, fmt.Errorf("parse %s: %w", k, err)
+		}
+	}
+	return a, nil
+}
+
+func (a EmbedCoverAddon) ProcessRelease(ctx context.Context, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	coverPath, err := findCover(filepath.Dir(paths[0]))
+	if err != nil {
+		return fmt.Errorf("find cover: %w", err)
+	}
+	if coverPath == "" {
+		return nil
+	}
+
+	pic, err := a.picture(coverPath)
+	if err != nil {
+		return fmt.Errorf("prepare cover: %w", err)
+	}
+	if a.maxSize > 0 && len(pic.Data) > a.maxSize {
+		slog.WarnContext(ctx, "cover too large to embed", "path", coverPath, "size", len(pic.Data), "max_size", a.maxSize)
+		return nil
+	}
+
+	var pathErrs []error
+	for _, path := range paths {
+		if !tags.CanWritePictures(path) {
+			slog.DebugContext(ctx, "can't embed cover in format", "path", path)
+			continue
+		}
+		existing, err := tags.ReadPictures(path)
+		if err != nil {
+			pathErrs = append(pathErrs, fmt.Errorf("read pictures: %w", err))
+			continue
+		}
+		if a.keep && len(existing) > 0 {
+			continue
+		}
+		if slices.ContainsFunc(existing, func(p tags.Picture) bool {
+			return p.Type == tags.PictureFrontCover && bytes.Equal(p.Data, pic.Data)
+		}) {
+			continue // already embedded, so the file doesn't need rewriting
+		}
+		if err := tags.WritePictures(path, []tags.Picture{pic}, true); err != nil {
+			pathErrs = append(pathErrs, fmt.Errorf("write pictures: %w", err))
+			continue
+		}
+	}
+	return errors.Join(pathErrs...)
+}
+
+func (a EmbedCoverAddon) picture(coverPath string) (tags.Picture, error) {
+	data, err := os.ReadFile(coverPath)
+	if err != nil {
+		return tags.Picture{}, fmt.Errorf("read cover: %w", err)
+	}
+	config, mime, err := imageutil.DecodeConfig(data)
+	if err != nil {
+		return tags.Picture{}, err
+	}
+
+	tooLarge := a.maxSize > 0 && len(data) > a.maxSize
+	tooBig := a.resize > 0 && max(config.Width, config.Height) > a.resize
+	if tooLarge || tooBig {
+		data, err = imageutil.ScaleJPEG(data, a.resize, imageutil.DefaultJPEGQuality)
+		if err != nil {
+			return tags.Picture{}, fmt.Errorf("scale: %w", err)
+		}
+		config, mime, err = imageutil.DecodeConfig(data)
+		if err != nil {
+			return tags.Picture{}, err
+		}
+	}
+
+	return tags.Picture{
+		Type:     tags.PictureFrontCover,
+		MIMEType: mime,
+		Width:    config.Width,
+		Height:   config.Height,
+		Data:     data,
+	}, nil
+}
+
+func (a EmbedCoverAddon) String() string {
+	return fmt.Sprintf("embedcover (max size %d, resize %d, keep %t)", a.maxSize, a.resize, a.keep)
+}
+
+// findCover finds the cover that wrtag placed next to the tracks. Its name can be configured, so the best image in
+// the directory is used.
+func findCover(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+	var cover coverparse.Cover
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !coverparse.IsCover(e.Name()) {
+			continue
+		}
+		c, err := coverparse.Stat(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return "", err
+		}
+		coverparse.BestCoverBetween(&cover, c)
+	}
+	return cover.Path, nil
+}
+
+// parseSize parses a number of bytes like "500000", "500k", or "2m".
+func parseSize(s string) (int, error) {
+	mult := 1
+	switch {
+	case strings.HasSuffix(strings.ToLower(s), "k"):
+		mult, s = 1<<10, s[:len(s)-1]
+	case strings.HasSuffix(strings.ToLower(s), "m"):
+		mult, s = 1<<20, s[:len(s)-1]
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return n * mult, nil
+}
@@ -1,12 +1,18 @@
 package clientutil
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/gregjones/httpcache"
+	"golang.org/x/sync/singleflight"
 	"golang.org/x/time/rate"
 )
 
@@ -38,7 +44,7 @@ func WithRateLimit(interval time.Duration) Middleware {
 		return Passthrough
 	}
 	return func(next http.RoundTripper) http.RoundTripper {
-		limiter := rate.NewLimiter(rate.Every(interval), 1)
+		limiter := &priorityLimiter{limiter: rate.NewLimiter(rate.Every(interval), 1), cancelled: make(chan struct{}, 1)}
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
 			if err := limiter.Wait(r.Context()); err != nil {
 				return nil, err
@@ -48,6 +54,188 @@ func WithRateLimit(interval time.Duration) Middleware {
 	}
 }
 
+// WithDedupe coalesces concurrent identical GET requests so that only one of them reaches the next
+// RoundTripper. Every caller receives its own copy of the response. Requests with different priorities aren't
+// coalesced, so that a request never waits on a flight that has a lower priority than its own.
+func WithDedupe() Middleware {
+	var group singleflight.Group
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+			if r.Method != http.MethodGet {
+				return next.RoundTrip(r)
+			}
+
+			key := fmt.Sprintf("%d %s", PriorityFrom(r.Context()), r.URL)
+			ch := group.DoChan(key, func() (any, error) {
+				// the flight is shared, so it shouldn't fail for the other callers if this one gives up
+				resp, err := next.RoundTrip(r.WithContext(context.WithoutCancel(r.Context())))
+				if err != nil {
+					return nil, err
+				}
+				defer resp.Body.Close()
+
+				body, err := io.ReadAll(resp.Body)
+				if err != nil {
+					return nil, err
+				}
+				return sharedResponse{resp, body}, nil
+			})
+
+			select {
+			case <-r.Context().Done():
+				return nil, r.Context().Err()
+			case res := <-ch:
+				if res.Err != nil {
+					return nil, res.Err
+				}
+				return res.Val.(sharedResponse).clone(r), nil
+			}
+		})
+	}
+}
+
+type sharedResponse struct {
+	resp *http.Response
+	body []byte
+}
+
+func (sr sharedResponse) clone(r *http.Request) *http.Response {
+	resp := *sr.resp
+	resp.Header = sr.resp.Header.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(sr.body))
+	resp.ContentLength = int64(len(sr.body))
+	resp.Request = r
+	return &resp
+}
+
+// Priority controls the order in which requests waiting on a rate limit are let through.
+type Priority uint8
+
+const (
+	PriorityBackground Priority = iota
+	PriorityNormal
+	PriorityInteractive
+	numPriorities
+)
+
+type priorityKey struct{}
+
+// WithPriority returns a context which marks requests made with it as having priority p. Requests with
+// no priority set are treated as [PriorityNormal].
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the request priority for ctx.
+func PriorityFrom(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p < numPriorities {
+		return p
+	}
+	return PriorityNormal
+}
+
+// priorityLimiter hands out tokens from limiter to the oldest waiter of the highest priority.
+type priorityLimiter struct {
+	limiter   *rate.Limiter
+	cancelled chan struct{} // signalled when a waiter gives up
+
+	mu      sync.Mutex
+	queues  [numPriorities][]chan struct{}
+	running bool
+}
+
+func (pl *priorityLimiter) Wait(ctx context.Context) error {
+	p := PriorityFrom(ctx)
+	ready := make(chan struct{})
+
+	pl.mu.Lock()
+	pl.queues[p] = append(pl.queues[p], ready)
+	if !pl.running {
+		pl.running = true
+		go pl.dispatch()
+	}
+	pl.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		pl.mu.Lock()
+		defer pl.mu.Unlock()
+		if i := slices.Index(pl.queues[p], ready); i >= 0 {
+			pl.queues[p] = slices.Delete(pl.queues[p], i, i+1)
+		}
+		select {
+		case pl.cancelled <- struct{}{}:
+		default:
+		}
+		return ctx.Err()
+	}
+}
+
+func (pl *priorityLimiter) dispatch() {
+	for {
+		pl.mu.Lock()
+		if !pl.waiting() {
+			pl.running = false
+			pl.mu.Unlock()
+			return
+		}
+		pl.mu.Unlock()
+
+		if !pl.reserve() {
+			continue
+		}
+
+		pl.mu.Lock()
+		if ready := pl.pop(); ready != nil {
+			close(ready)
+		}
+		pl.mu.Unlock()
+	}
+}
+
+// reserve waits for a token from the limiter. If every waiter gives up before then, the token is handed back and
+// it returns false.
+func (pl *priorityLimiter) reserve() bool {
+	r := pl.limiter.Reserve()
+	timer := time.NewTimer(r.Delay())
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+			return true
+		case <-pl.cancelled:
+			pl.mu.Lock()
+			waiting := pl.waiting()
+			pl.mu.Unlock()
+			if !waiting {
+				r.Cancel()
+				return false
+			}
+		}
+	}
+}
+
+func (pl *priorityLimiter) waiting() bool {
+	for _, q := range pl.queues {
+		if len(q) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (pl *priorityLimiter) pop() chan struct{} {
+	for p := numPriorities; p > 0; p-- {
+		if q := pl.queues[p-1]; len(q) > 0 {
+			pl.queues[p-1] = q[1:]
+			return q[0]
+		}
+	}
+	return nil
+}
+
 func WithLogging(logger *slog.Logger) Middleware {
 	return func(next http.RoundTripper) http.RoundTripper {
 		return RoundTripFunc(func(r *http.Request) (*http.Response, error) {
@@ -0,0 +1,201 @@
+package clientutil_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.senan.xyz/wrtag/clientutil"
+)
+
+func TestDedupe(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	final := clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(r.URL.Path))}, nil
+	})
+	client := clientutil.Wrap(&http.Client{Transport: final}, clientutil.WithDedupe())
+
+	const n = 8
+	var wg sync.WaitGroup
+	bodies := make([]string, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get("http://example.com/same")
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(body)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond) // let everyone join the flight
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, b := range bodies {
+		assert.Equal(t, "/same", b) // each caller gets a full body
+	}
+}
+
+func TestDedupeLeaderCancelled(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	final := clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		close(started)
+		<-release
+		if err := r.Context().Err(); err != nil {
+			return nil, err
+		}
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	client := clientutil.Wrap(&http.Client{Transport: final}, clientutil.WithDedupe())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	leaderReq, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/same", nil)
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := client.Do(leaderReq)
+		leaderErr <- err
+	}()
+	<-started
+
+	waiterResp := make(chan *http.Response, 1)
+	go func() {
+		resp, err := client.Get("http://example.com/same")
+		assert.NoError(t, err)
+		waiterResp <- resp
+	}()
+
+	time.Sleep(50 * time.Millisecond) // let the waiter join the flight
+	cancel()
+	assert.ErrorIs(t, <-leaderErr, context.Canceled)
+	close(release)
+
+	resp := <-waiterResp
+	if assert.NotNil(t, resp) {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "ok", string(body))
+	}
+}
+
+func TestDedupePriorities(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	final := clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+	})
+	client := clientutil.Wrap(&http.Client{Transport: final}, clientutil.WithDedupe())
+
+	var wg sync.WaitGroup
+	for _, p := range []clientutil.Priority{clientutil.PriorityBackground, clientutil.PriorityInteractive} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx := clientutil.WithPriority(context.Background(), p)
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com/same", nil)
+			resp, err := client.Do(req)
+			if assert.NoError(t, err) {
+				resp.Body.Close()
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(2), calls.Load()) // the interactive request didn't wait on the background flight
+}
+
+func TestRateLimitCancelledWaiter(t *testing.T) {
+	t.Parallel()
+
+	final := clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := clientutil.Wrap(&http.Client{Transport: final}, clientutil.WithRateLimit(200*time.Millisecond))
+
+	get := func(ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	require.NoError(t, get(context.Background())) // use up the burst
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, get(ctx), context.DeadlineExceeded)
+
+	// the cancelled waiter's token is handed back, so this one doesn't wait for another
+	time.Sleep(250 * time.Millisecond)
+	start := time.Now()
+	require.NoError(t, get(context.Background()))
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRateLimitPriority(t *testing.T) {
+	t.Parallel()
+
+	var mu sync.Mutex
+	var order []string
+	final := clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		order = append(order, r.URL.Path)
+		mu.Unlock()
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	client := clientutil.Wrap(&http.Client{Transport: final}, clientutil.WithRateLimit(50*time.Millisecond))
+
+	get := func(p clientutil.Priority, path string) {
+		ctx := clientutil.WithPriority(context.Background(), p)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com"+path, nil)
+		resp, err := client.Do(req)
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}
+
+	get(clientutil.PriorityNormal, "/first") // use up the burst
+
+	var wg sync.WaitGroup
+	for _, path := range []string{"/bg-1", "/bg-2"} {
+		wg.Add(1)
+		go func() { defer wg.Done(); get(clientutil.PriorityBackground, path) }()
+	}
+	time.Sleep(10 * time.Millisecond)
+	wg.Add(1)
+	go func() { defer wg.Done(); get(clientutil.PriorityInteractive, "/interactive") }()
+	wg.Wait()
+
+	require.Len(t, order, 4)
+	assert.Equal(t, "/first", order[0])
+	assert.Equal(t, "/interactive", order[1])
+}
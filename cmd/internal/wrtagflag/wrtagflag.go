@@ -5,10 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -17,11 +19,18 @@ import (
 	"go.senan.xyz/wrtag"
 	"go.senan.xyz/wrtag/addon"
 	"go.senan.xyz/wrtag/clientutil"
+	"go.senan.xyz/wrtag/cuesplit"
+	"go.senan.xyz/wrtag/ignore"
+	"go.senan.xyz/wrtag/imageutil"
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/nameparse"
 	"go.senan.xyz/wrtag/notifications"
 	"go.senan.xyz/wrtag/pathformat"
 	"go.senan.xyz/wrtag/researchlink"
 	"go.senan.xyz/wrtag/tagmap"
+	"go.senan.xyz/wrtag/tags"
 
+	_ "go.senan.xyz/wrtag/addon/embedcover"
 	_ "go.senan.xyz/wrtag/addon/lyrics"
 	_ "go.senan.xyz/wrtag/addon/replaygain"
 	_ "go.senan.xyz/wrtag/addon/subproc"
@@ -77,13 +86,64 @@ func Config() *wrtag.Config {
 	cfg.TagWeights = tagmap.TagWeights{}
 	flag.Var(&tagWeightsParser{cfg.TagWeights}, "tag-weight", "Adjust distance weighting for a tag (0 to ignore) (stackable)")
 
+	flag.Var(&tagRulesParser{&cfg.TagRules}, "tag-template", "Set a tag from a template, eg. \"GROUPING {{ .Release.ReleaseGroup.PrimaryType }}\" (see [Tag templates](#tag-templates)) (stackable)")
+
+	cfg.TagMerge = tagmap.MergeStrategies{}
+	flag.Var(&tagMergeParser{cfg.TagMerge}, "tag-merge", "Set how a tag's local value is merged with MusicBrainz, eg. \"GENRES union\" or \"LABEL remote-if-nonempty\" (stackable)")
+
+	cfg.TagRetention.Allow = map[string]struct{}{}
+	flag.Var(&retentionModeParser{&cfg.TagRetention.Mode}, "tag-retention", "Which existing tags to keep when they aren't written by wrtag, one of all, known, or allowed")
+	flag.Var(&tagAllowParser{cfg.TagRetention.Allow}, "tag-allow", "Define an existing tag to always keep, eg. \"REPLAYGAIN_TRACK_GAIN\" (stackable)")
+
+	cfg.Relationships = tagmap.Relationships{}
+	flag.Var(&relationshipsParser{cfg.Relationships, &cfg.MusicBrainzClient.Relationships}, "relationship", "Write a tag from MusicBrainz relationships, eg. composer or performer (stackable)")
+
+	cfg.GenrePolicy.Allow = map[string]struct{}{}
+	cfg.GenrePolicy.Deny = map[string]struct{}{}
+	cfg.GenrePolicy.Aliases = map[string]string{}
+	cfg.GenrePolicy.Weights = map[musicbrainz.GenreSource]float64{}
+	flag.Var(&genreSetParser{cfg.GenrePolicy.Allow}, "genre-allow", "Only write these genres, if any are set (stackable)")
+	flag.Var(&genreSetParser{cfg.GenrePolicy.Deny}, "genre-deny", "Never write these genres (stackable)")
+	flag.Var(&genreAliasParser{cfg.GenrePolicy.Aliases}, "genre-alias", "Rename a genre, eg. \"hip hop = Hip-Hop\" (stackable)")
+	flag.Var(&genreTreeParser{tree: &cfg.GenrePolicy.Tree}, "genre-tree", "Path to a genre tree file, where votes for a genre also count for its parents")
+	flag.IntVar(&cfg.GenrePolicy.MinCount, "genre-min-count", 0, "Number of votes a genre needs to be written")
+	flag.Var(&genreWeightsParser{cfg.GenrePolicy.Weights}, "genre-weight", "Adjust the votes from a genre source, eg. \"artist 0.5\" (stackable)")
+
+	flag.BoolVar(&cfg.FeatMatch, "feat-match", false, "Match tracks without regard to whether featured artists are in the artist or title")
+	flag.Var(&featStyleParser{&cfg.FeatStyle}, "feat-style", "Where to write featured artists, one of credit, artist, title, or both. Styles other than credit imply feat-match")
+
+	flag.Var(&commaListParser{&cfg.Locales}, "locale", "Preferred locales for artist names from their aliases, eg. \"ja,en\"")
+	flag.StringVar(&cfg.LocaleScript, "locale-script", "", "Script to prefer for titles, taken from a pseudo-release if available, eg. \"Latn\"")
+
+	flag.Var(&classicalParser{&cfg.Classical, &cfg.PathFormat.Classical, &cfg.MusicBrainzClient.Relationships}, "classical", "Write work and movement tags for classical music")
+
 	flag.StringVar(&cfg.MusicBrainzClient.BaseURL, "mb-base-url", `https://musicbrainz.org/ws/2/`, "MusicBrainz base URL")
 	flag.DurationVar(&cfg.MusicBrainzClient.RateLimit, "mb-rate-limit", 1*time.Second, "MusicBrainz rate limit duration")
 
 	flag.StringVar(&cfg.CoverArtArchiveClient.BaseURL, "caa-base-url", `https://coverartarchive.org/`, "CoverArtArchive base URL")
 	flag.DurationVar(&cfg.CoverArtArchiveClient.RateLimit, "caa-rate-limit", 0, "CoverArtArchive rate limit duration")
 
-	flag.BoolVar(&cfg.UpgradeCover, "cover-upgrade", false, "Fetch new cover art even if it exists locally")
+	flag.StringVar(&cfg.AcoustIDClient.BaseURL, "acoustid-base-url", `https://api.acoustid.org/v2/`, "AcoustID base URL")
+	flag.StringVar(&cfg.AcoustIDClient.APIKey, "acoustid-api-key", "", "AcoustID API key, enables fingerprint lookups for releases without tags (requires fpcalc)")
+	flag.DurationVar(&cfg.AcoustIDClient.RateLimit, "acoustid-rate-limit", 334*time.Millisecond, "AcoustID rate limit duration")
+
+	flag.BoolVar(&cfg.UpgradeCover, "cover-upgrade", false, "Fetch new cover art even if it exists locally or is embedded in the tracks, replacing it if the new one is larger")
+	flag.Int64Var(&cfg.CoverPolicy.MaxBytes, "cover-max-bytes", 8<<20, "Largest cover to download, falling back to a smaller Cover Art Archive thumbnail if the original is larger")
+	flag.IntVar(&cfg.CoverPolicy.Resolution, "cover-resolution", 0, "Preferred size of downloaded covers in pixels, eg. 1200 or 500 for a Cover Art Archive thumbnail instead of the original")
+	flag.IntVar(&cfg.CoverPolicy.Resize, "cover-resize", 0, "Scale covers down so neither side is larger than this many pixels")
+	flag.IntVar(&cfg.CoverPolicy.Quality, "cover-quality", imageutil.DefaultJPEGQuality, "JPEG quality for scaled or converted covers")
+	flag.BoolVar(&cfg.CoverPolicy.ConvertJPEG, "cover-jpeg", false, "Convert PNG, GIF, and BMP covers to JPEG")
+	flag.Var(&commaListParser{&cfg.ScanTypes}, "cover-scans", "Extra Cover Art Archive images to download by type, eg. \"back,booklet,medium\"")
+	flag.StringVar(&cfg.ScansDir, "cover-scans-dir", "scans", "Directory in the release directory for extra Cover Art Archive images")
+	flag.Var(&coverNamesParser{&cfg.CoverPolicy.Names}, "cover-name", "File name for the cover without extension, eg. \"folder\" or \"{{ .Release.Title | safepath }}\" (default \"cover\") (stackable)")
+
+	flag.Var(&archiveActionParser{&cfg.ArchiveAction}, "archive-action", "What to do with a zip or tar archive once it's imported, one of keep, delete, or move")
+	flag.StringVar(&cfg.ArchiveDoneDir, "archive-done-dir", "", "Directory to move imported archives to, with archive-action move")
+	flag.Var(&cueSplitToolParser{&cfg.CueSplitTool}, "cue-split-tool", "Tool to split a single file CD image into tracks using its CUE sheet, one of ffmpeg or shnsplit")
+
+	flag.Var(&namePatternsParser{&cfg.DirPatterns}, "dir-pattern", "Pattern for details in release directory names when tags are missing, eg. \"{artist} - {album} ({year})\" (see [Name patterns](#name-patterns)) (stackable)")
+	flag.Var(&namePatternsParser{&cfg.FilePatterns}, "file-pattern", "Pattern for details in track file names when tags are missing, eg. \"{track} - {title}\" (see [Name patterns](#name-patterns)) (stackable)")
+	flag.Var(&ignorePatternsParser{&cfg.Ignore}, "ignore", "Gitignore-style pattern for paths to leave out when reading releases and syncing, eg. \"_incomplete/\" (see [Ignoring paths](#ignoring-paths)) (stackable)")
 
 	return &cfg
 }
@@ -119,6 +179,23 @@ var _ flag.Value = (*notificationsParser)(nil)
 var _ flag.Value = (*tagWeightsParser)(nil)
 var _ flag.Value = (*keepFileParser)(nil)
 var _ flag.Value = (*addonsParser)(nil)
+var _ flag.Value = (*relationshipsParser)(nil)
+var _ flag.Value = (*classicalParser)(nil)
+var _ flag.Value = (*commaListParser)(nil)
+var _ flag.Value = (*tagRulesParser)(nil)
+var _ flag.Value = (*coverNamesParser)(nil)
+var _ flag.Value = (*namePatternsParser)(nil)
+var _ flag.Value = (*ignorePatternsParser)(nil)
+var _ flag.Value = (*retentionModeParser)(nil)
+var _ flag.Value = (*tagAllowParser)(nil)
+var _ flag.Value = (*tagMergeParser)(nil)
+var _ flag.Value = (*featStyleParser)(nil)
+var _ flag.Value = (*archiveActionParser)(nil)
+var _ flag.Value = (*cueSplitToolParser)(nil)
+var _ flag.Value = (*genreSetParser)(nil)
+var _ flag.Value = (*genreAliasParser)(nil)
+var _ flag.Value = (*genreTreeParser)(nil)
+var _ flag.Value = (*genreWeightsParser)(nil)
 
 type pathFormatParser struct{ *pathformat.Format }
 
@@ -221,6 +298,333 @@ func (kf *keepFileParser) String() string {
 	return strings.Join(parts, ", ")
 }
 
+type coverNamesParser struct{ names *[]wrtag.CoverName }
+
+func (cn coverNamesParser) Set(value string) error {
+	name, err := wrtag.ParseCoverName(value)
+	if err != nil {
+		return fmt.Errorf("parse cover name: %w", err)
+	}
+	*cn.names = append(*cn.names, name)
+	return nil
+}
+func (cn *coverNamesParser) String() string {
+	if cn.names == nil {
+		return ""
+	}
+	var parts []string
+	for _, n := range *cn.names {
+		parts = append(parts, n.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+type namePatternsParser struct{ patterns *[]nameparse.Pattern }
+
+func (np namePatternsParser) Set(value string) error {
+	pattern, err := nameparse.ParsePattern(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("parse pattern: %w", err)
+	}
+	*np.patterns = append(*np.patterns, pattern)
+	return nil
+}
+func (np *namePatternsParser) String() string {
+	if np.patterns == nil {
+		return ""
+	}
+	var parts []string
+	for _, p := range *np.patterns {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+type ignorePatternsParser struct{ patterns *[]ignore.Pattern }
+
+func (ip ignorePatternsParser) Set(value string) error {
+	pattern, err := ignore.ParsePattern(strings.TrimSpace(value))
+	if err != nil {
+		return fmt.Errorf("parse pattern: %w", err)
+	}
+	*ip.patterns = append(*ip.patterns, pattern)
+	return nil
+}
+func (ip *ignorePatternsParser) String() string {
+	if ip.patterns == nil {
+		return ""
+	}
+	var parts []string
+	for _, p := range *ip.patterns {
+		parts = append(parts, p.String())
+	}
+	return strings.Join(parts, ", ")
+}
+
+type tagRulesParser struct{ rules *[]tagmap.TagRule }
+
+func (tr tagRulesParser) Set(value string) error {
+	key, text, _ := strings.Cut(strings.TrimSpace(value), " ")
+	if key == "" {
+		return fmt.Errorf("invalid tag template format. expected eg \"GROUPING {{ .Release.ReleaseGroup.PrimaryType }}\"")
+	}
+	rule, err := tagmap.ParseTagRule(key, text)
+	if err != nil {
+		return fmt.Errorf("parse tag rule: %w", err)
+	}
+	*tr.rules = append(*tr.rules, rule)
+	return nil
+}
+func (tr *tagRulesParser) String() string {
+	if tr.rules == nil {
+		return ""
+	}
+	var parts []string
+	for _, r := range *tr.rules {
+		parts = append(parts, r.Key)
+	}
+	return strings.Join(parts, ", ")
+}
+
+type retentionModeParser struct{ mode *tagmap.RetentionMode }
+
+func (r retentionModeParser) Set(value string) error {
+	mode, err := tagmap.ParseRetentionMode(value)
+	if err != nil {
+		return err
+	}
+	*r.mode = mode
+	return nil
+}
+func (r *retentionModeParser) String() string {
+	if r.mode == nil {
+		return ""
+	}
+	return r.mode.String()
+}
+
+type tagAllowParser struct{ m map[string]struct{} }
+
+func (ta tagAllowParser) Set(value string) error {
+	ta.m[tags.NormKey(strings.TrimSpace(value))] = struct{}{}
+	return nil
+}
+func (ta *tagAllowParser) String() string {
+	return strings.Join(slices.Sorted(maps.Keys(ta.m)), ", ")
+}
+
+type tagMergeParser struct{ tagmap.MergeStrategies }
+
+func (tm tagMergeParser) Set(value string) error {
+	const sep = " "
+	i := strings.LastIndex(value, sep)
+	if i < 0 {
+		return fmt.Errorf("invalid tag merge format. expected eg \"GENRES union\"")
+	}
+	tag := tags.NormKey(strings.TrimSpace(value[:i]))
+	strategy, err := tagmap.ParseMergeStrategy(value[i+len(sep):])
+	if err != nil {
+		return err
+	}
+	tm.MergeStrategies[tag] = strategy
+	return nil
+}
+func (tm tagMergeParser) String() string {
+	var parts []string
+	for _, k := range slices.Sorted(maps.Keys(tm.MergeStrategies)) {
+		parts = append(parts, fmt.Sprintf("%s: %s", k, tm.MergeStrategies[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+type relationshipsParser struct {
+	rels    tagmap.Relationships
+	request *bool
+}
+
+func (r relationshipsParser) Set(value string) error {
+	tag := strings.ToUpper(strings.TrimSpace(value))
+	if !slices.Contains(tagmap.RelationshipTags, tag) {
+		return fmt.Errorf("unknown relationship tag %q. expected one of %s", value, strings.ToLower(strings.Join(tagmap.RelationshipTags, ", ")))
+	}
+	r.rels[tag] = struct{}{}
+	*r.request = true
+	return nil
+}
+func (r *relationshipsParser) String() string {
+	var parts []string
+	for k := range r.rels {
+		parts = append(parts, strings.ToLower(k))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, ", ")
+}
+
+type classicalParser struct {
+	classical     *bool
+	pathClassical *bool
+	request       *bool
+}
+
+func (c classicalParser) Set(value string) error {
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("parse bool: %w", err)
+	}
+	*c.classical, *c.pathClassical = v, v
+	*c.request = *c.request || v
+	return nil
+}
+func (c *classicalParser) String() string {
+	return strconv.FormatBool(c.classical != nil && *c.classical)
+}
+func (c classicalParser) IsBoolFlag() bool { return true }
+
+type archiveActionParser struct{ action *wrtag.ArchiveAction }
+
+func (a archiveActionParser) Set(value string) error {
+	action, err := wrtag.ParseArchiveAction(value)
+	if err != nil {
+		return err
+	}
+	*a.action = action
+	return nil
+}
+func (a *archiveActionParser) String() string {
+	if a.action == nil {
+		return ""
+	}
+	return a.action.String()
+}
+
+type cueSplitToolParser struct{ tool *cuesplit.Tool }
+
+func (c cueSplitToolParser) Set(value string) error {
+	tool, err := cuesplit.ParseTool(value)
+	if err != nil {
+		return err
+	}
+	*c.tool = tool
+	return nil
+}
+func (c *cueSplitToolParser) String() string {
+	if c.tool == nil {
+		return ""
+	}
+	return c.tool.String()
+}
+
+type featStyleParser struct{ style *tagmap.FeatStyle }
+
+func (f featStyleParser) Set(value string) error {
+	style, err := tagmap.ParseFeatStyle(value)
+	if err != nil {
+		return err
+	}
+	*f.style = style
+	return nil
+}
+func (f *featStyleParser) String() string {
+	if f.style == nil {
+		return ""
+	}
+	return f.style.String()
+}
+
+type commaListParser struct{ list *[]string }
+
+func (l commaListParser) Set(value string) error {
+	*l.list = nil
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l.list = append(*l.list, item)
+		}
+	}
+	return nil
+}
+func (l *commaListParser) String() string {
+	if l.list == nil {
+		return ""
+	}
+	return strings.Join(*l.list, ",")
+}
+
+type genreSetParser struct{ m map[string]struct{} }
+
+func (g genreSetParser) Set(value string) error {
+	g.m[strings.ToLower(strings.TrimSpace(value))] = struct{}{}
+	return nil
+}
+func (g *genreSetParser) String() string {
+	parts := slices.Sorted(maps.Keys(g.m))
+	return strings.Join(parts, ", ")
+}
+
+type genreAliasParser struct{ m map[string]string }
+
+func (g genreAliasParser) Set(value string) error {
+	from, to, ok := strings.Cut(value, "=")
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if !ok || from == "" || to == "" {
+		return fmt.Errorf("invalid genre alias format. expected eg \"hip hop = Hip-Hop\"")
+	}
+	g.m[strings.ToLower(from)] = to
+	return nil
+}
+func (g *genreAliasParser) String() string {
+	var parts []string
+	for _, from := range slices.Sorted(maps.Keys(g.m)) {
+		parts = append(parts, fmt.Sprintf("%s: %s", from, g.m[from]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+type genreTreeParser struct {
+	tree *tagmap.GenreTree
+	path string
+}
+
+func (g *genreTreeParser) Set(value string) error {
+	tree, err := tagmap.ParseGenreTreeFile(value)
+	if err != nil {
+		return fmt.Errorf("parse genre tree: %w", err)
+	}
+	*g.tree = tree
+	g.path = value
+	return nil
+}
+func (g genreTreeParser) String() string {
+	return g.path
+}
+
+type genreWeightsParser struct {
+	m map[musicbrainz.GenreSource]float64
+}
+
+func (g genreWeightsParser) Set(value string) error {
+	srcStr, weightStr, ok := strings.Cut(strings.TrimSpace(value), " ")
+	if !ok {
+		return fmt.Errorf("invalid genre weight format. expected eg \"artist 0.5\"")
+	}
+	src := musicbrainz.GenreSource(strings.TrimSpace(srcStr))
+	if !slices.Contains(musicbrainz.GenreSources, src) {
+		return fmt.Errorf("unknown genre source %q", src)
+	}
+	weight, err := strconv.ParseFloat(strings.TrimSpace(weightStr), 64)
+	if err != nil {
+		return fmt.Errorf("parse weight: %w", err)
+	}
+	g.m[src] = weight
+	return nil
+}
+func (g *genreWeightsParser) String() string {
+	var parts []string
+	for _, src := range slices.Sorted(maps.Keys(g.m)) {
+		parts = append(parts, fmt.Sprintf("%s: %.2f", src, g.m[src]))
+	}
+	return strings.Join(parts, ", ")
+}
+
 type addonsParser struct {
 	addons *[]addon.Addon
 }
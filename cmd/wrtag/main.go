@@ -1,6 +1,7 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"flag"
@@ -21,9 +22,11 @@ import (
 	"go.senan.xyz/table/table"
 
 	"go.senan.xyz/wrtag"
+	"go.senan.xyz/wrtag/clientutil"
 	"go.senan.xyz/wrtag/cmd/internal/logging"
 	"go.senan.xyz/wrtag/cmd/internal/wrtagflag"
 	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/ignore"
 	"go.senan.xyz/wrtag/researchlink"
 )
 
@@ -139,6 +142,12 @@ func main() {
 
 		start := time.Now()
 
+		if !*dryRun {
+			if err := wrtag.RemoveStaleStagingDirs(cfg); err != nil {
+				slog.Error("removing stale staging dirs", "err", err)
+			}
+		}
+
 		var stats syncStats
 		if err := runSync(ctx, cfg, &stats, dirs, *ageYounger, *ageOlder, *dryRun, *numWorkers); err != nil {
 			slog.Error("running", "command", command, "err", err)
@@ -167,6 +176,10 @@ func runOperation(
 	op wrtag.FileSystemOperation, srcDir string, cond wrtag.ImportCondition, useMBID string,
 ) error {
 	r, searchErr := wrtag.ProcessDir(ctx, cfg, op, srcDir, cond, useMBID)
+	if errors.Is(searchErr, wrtag.ErrSkipped) {
+		slog.InfoContext(ctx, "skipping dir", "dir", srcDir, "reason", searchErr)
+		return nil
+	}
 	if searchErr != nil && !wrtag.IsNonFatalError(searchErr) {
 		return fmt.Errorf("processing: %w", searchErr)
 	}
@@ -188,7 +201,7 @@ func runOperation(
 		Artist: r.Query.Artist,
 		Album:  r.Query.Release,
 		UPC:    r.Query.Barcode,
-		Date:   r.Query.Date,
+		Date:   r.Query.Date.Time,
 	})
 	if err != nil {
 		return fmt.Errorf("research search: %w", err)
@@ -209,10 +222,51 @@ const (
 )
 
 func runSync(ctx context.Context, cfg *wrtag.Config, stats *syncStats, dirs []string, ageYounger, ageOlder time.Duration, dryRun bool, numWorkers int) error {
+	// let any interactive requests sharing our clients go first
+	ctx = clientutil.WithPriority(ctx, clientutil.PriorityBackground)
+
 	leaves := make(chan string)
 	go func() {
 		for _, d := range dirs {
-			err := fileutil.WalkLeaves(d, func(path string, _ fs.DirEntry) error {
+			// each dir's matcher has the .wrtagignore files from the root down to it
+			root, err := ignore.New(d, cfg.Ignore)
+			if err != nil {
+				slog.Error("reading ignore files", "err", err)
+				continue
+			}
+			matchers := map[string]ignore.Matcher{filepath.Clean(d): root}
+			skip := func(path string, d fs.DirEntry) error {
+				parent, ok := matchers[filepath.Dir(path)]
+				if !ok {
+					return nil // the root
+				}
+				if strings.HasPrefix(d.Name(), wrtag.StagingDirPrefix) {
+					// an archive or image that another import is still working on
+					return filepath.SkipDir
+				}
+				if d.Name() == cmp.Or(cfg.ScansDir, "scans") {
+					// the scans belong to the release above them
+					return fileutil.SkipPart
+				}
+				if parent.Ignored(path, true) {
+					stats.ignored.Add(1)
+					slog.DebugContext(ctx, "ignoring dir", "dir", path)
+					return filepath.SkipDir
+				}
+				m, err := parent.WithFile(path)
+				if err != nil {
+					slog.ErrorContext(ctx, "reading ignore file", "dir", path, "err", err)
+					m = parent
+				}
+				matchers[path] = m
+				return nil
+			}
+
+			err = fileutil.WalkLeaves(d, skip, func(path string, _ fs.DirEntry) error {
+				// files in the leaf are left out when its release is read, so they're ignored by the sync too
+				if m, ok := matchers[path]; ok {
+					stats.ignored.Add(ignoredFiles(m, path))
+				}
 				leaves <- path
 				return nil
 			})
@@ -231,7 +285,11 @@ func runSync(ctx context.Context, cfg *wrtag.Config, stats *syncStats, dirs []st
 			defer wg.Done()
 			ctxConsume(ctx, leaves, func(dir string) {
 				stats.saw.Add(1)
-				r, err := syncDir(ctx, cfg, ageYounger, ageOlder, wrtag.NewMove(dryRun), dir)
+				r, err := syncDir(ctx, cfg, ageYounger, ageOlder, dryRun, dir)
+				if errors.Is(err, wrtag.ErrSkipped) {
+					stats.skipped.Add(1)
+					return
+				}
 				if err != nil && !errors.Is(err, context.Canceled) {
 					stats.errors.Add(1)
 					slog.ErrorContext(ctx, "processing dir", "dir", dir, "err", err)
@@ -250,10 +308,27 @@ func runSync(ctx context.Context, cfg *wrtag.Config, stats *syncStats, dirs []st
 	return nil
 }
 
+// ignoredFiles counts the files in dir that m ignores.
+func ignoredFiles(m ignore.Matcher, dir string) uint64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var n uint64
+	for _, e := range entries {
+		if !e.IsDir() && m.Ignored(filepath.Join(dir, e.Name()), false) {
+			n++
+		}
+	}
+	return n
+}
+
 type syncStats struct {
 	saw       atomic.Uint64
 	processed atomic.Uint64
 	errors    atomic.Uint64
+	skipped   atomic.Uint64
+	ignored   atomic.Uint64
 }
 
 func (s *syncStats) LogValue() slog.Value {
@@ -261,6 +336,8 @@ func (s *syncStats) LogValue() slog.Value {
 		slog.Uint64("saw", s.saw.Load()),
 		slog.Uint64("processed", s.processed.Load()),
 		slog.Uint64("errors", s.errors.Load()),
+		slog.Uint64("skipped", s.skipped.Load()),
+		slog.Uint64("ignored", s.ignored.Load()),
 	)
 }
 
@@ -268,7 +345,7 @@ func (s *syncStats) String() string {
 	return s.LogValue().String()
 }
 
-func syncDir(ctx context.Context, cfg *wrtag.Config, ageYounger, ageOlder time.Duration, op wrtag.FileSystemOperation, srcDir string) (*wrtag.SearchResult, error) {
+func syncDir(ctx context.Context, cfg *wrtag.Config, ageYounger, ageOlder time.Duration, dryRun bool, srcDir string) (*wrtag.SearchResult, error) {
 	if ageYounger > 0 || ageOlder > 0 {
 		info, err := os.Stat(srcDir)
 		if err != nil {
@@ -282,6 +359,16 @@ func syncDir(ctx context.Context, cfg *wrtag.Config, ageYounger, ageOlder time.D
 		}
 	}
 
+	// releases are moved in place, unless the dir's overrides ask for a copy
+	var op wrtag.FileSystemOperation = wrtag.NewMove(dryRun)
+	overrides, err := wrtag.ReadOverrides(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("read overrides: %w", err)
+	}
+	if overrides != nil && overrides.Operation == "copy" {
+		op = wrtag.NewCopy(dryRun)
+	}
+
 	r, err := wrtag.ProcessDir(ctx, cfg, op, srcDir, wrtag.HighScoreOrMBID, "")
 	if err != nil {
 		return nil, err
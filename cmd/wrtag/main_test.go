@@ -1,10 +1,18 @@
 package main
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
 	"crypto/rand"
 	"embed"
 	"flag"
 	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
 	"io"
 	"io/fs"
 	"log"
@@ -16,9 +24,13 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/rogpeppe/go-internal/testscript"
+	"go.senan.xyz/wrtag/clientutil"
+	"go.senan.xyz/wrtag/cuesheet"
 	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/imageutil"
 	"go.senan.xyz/wrtag/tags"
 )
 
@@ -27,7 +39,7 @@ var responses embed.FS
 
 func TestMain(m *testing.M) {
 	var t http.Transport
-	t.RegisterProtocol("file", http.NewFileTransportFS(responses))
+	t.RegisterProtocol("file", withContentLength(http.NewFileTransportFS(responses)))
 
 	http.DefaultTransport = &t
 
@@ -35,6 +47,8 @@ func TestMain(m *testing.M) {
 	os.Setenv("WRTAG_MB_RATE_LIMIT", "0")
 	os.Setenv("WRTAG_CAA_BASE_URL", "file:///testdata/responses/coverartarchive")
 	os.Setenv("WRTAG_CAA_RATE_LIMIT", "0")
+	os.Setenv("WRTAG_ACOUSTID_BASE_URL", "file:///testdata/responses/acoustid")
+	os.Setenv("WRTAG_ACOUSTID_RATE_LIMIT", "0")
 
 	testscript.Main(m, map[string]func(){
 		"wrtag":    main,
@@ -44,6 +58,29 @@ func TestMain(m *testing.M) {
 		"mime":     mainMIME,
 		"mod-time": mainModTime,
 		"rand":     mainRand,
+		"fpcalc":   mainFpcalc,
+		"image":    mainImage,
+		"pictures": mainPictures,
+		"imgsize":  mainImageSize,
+		"embed":    mainEmbed,
+		"archive":  mainArchive,
+		"ffmpeg":   mainFFmpeg,
+		"shnsplit": mainShnsplit,
+	})
+}
+
+// withContentLength sets the response's ContentLength from its header, like the HTTP transport does and the file
+// transport doesn't
+func withContentLength(next http.RoundTripper) http.RoundTripper {
+	return clientutil.RoundTripFunc(func(r *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+			resp.ContentLength = n
+		}
+		return resp, nil
 	})
 }
 
@@ -131,6 +168,7 @@ func mainFind() {
 }
 
 func mainTouch() {
+	age := flag.Duration("age", 0, "set the mod time of the files and their directories this far in the past")
 	flag.Parse()
 
 	for _, p := range flag.Args() {
@@ -140,6 +178,15 @@ func mainTouch() {
 		if _, err := os.Create(p); err != nil {
 			log.Fatalf("err creating: %v", err)
 		}
+		if *age == 0 {
+			continue
+		}
+		t := time.Now().Add(-*age)
+		for _, p := range []string{p, filepath.Dir(p)} {
+			if err := os.Chtimes(p, t, t); err != nil {
+				log.Fatalf("err setting mod time: %v", err)
+			}
+		}
 	}
 }
 
@@ -191,6 +238,207 @@ func mainRand() {
 	_, _ = io.Copy(f, io.LimitReader(rand.Reader, int64(size)))
 }
 
+// mainFpcalc stands in for chromaprint's fpcalc, with the fingerprint being the file's name
+func mainFpcalc() {
+	flag.Bool("json", false, "")
+	flag.Parse()
+
+	if _, err := os.Stat(flag.Arg(0)); err != nil {
+		log.Fatalf("error stating: %v", err)
+	}
+	fmt.Printf(`{"duration": 120.2, "fingerprint": %q}`+"\n", filepath.Base(flag.Arg(0)))
+}
+
+// mainImage writes a PNG or JPEG image of the given size, depending on the extension
+func mainImage() {
+	flag.Parse()
+
+	path := flag.Arg(0)
+	w, _ := strconv.Atoi(flag.Arg(1))
+	h, _ := strconv.Atoi(flag.Arg(2))
+	if path == "" || w == 0 || h == 0 {
+		log.Fatalf("bad args")
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".png":
+		_ = png.Encode(&buf, img)
+	case ".jpg", ".jpeg":
+		_ = jpeg.Encode(&buf, img, nil)
+	default:
+		log.Fatalf("unknown image type %q", ext)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), os.ModePerm); err != nil {
+		log.Fatalf("error writing: %v", err)
+	}
+}
+
+// mainImageSize prints the MIME type and size of an image
+func mainImageSize() {
+	flag.Parse()
+
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("error reading image: %v", err)
+	}
+	config, mime, err := imageutil.DecodeConfig(data)
+	if err != nil {
+		log.Fatalf("error decoding image: %v", err)
+	}
+	fmt.Printf("%s %dx%d\n", mime, config.Width, config.Height)
+}
+
+// mainEmbed embeds an image in a file, as the front cover or another picture type
+func mainEmbed() {
+	flag.Parse()
+
+	data, err := os.ReadFile(flag.Arg(1))
+	if err != nil {
+		log.Fatalf("error reading image: %v", err)
+	}
+	typ := tags.PictureFrontCover
+	if flag.Arg(2) != "" {
+		n, _ := strconv.Atoi(flag.Arg(2))
+		typ = tags.PictureType(n)
+	}
+	config, mime, err := imageutil.DecodeConfig(data)
+	if err != nil {
+		log.Fatalf("error decoding image: %v", err)
+	}
+	pic := tags.Picture{Type: typ, MIMEType: mime, Width: config.Width, Height: config.Height, Data: data}
+	if err := tags.WritePictures(flag.Arg(0), []tags.Picture{pic}, true); err != nil {
+		log.Fatalf("error writing pictures: %v", err)
+	}
+}
+
+// mainPictures prints the type, MIME type, and size of the pictures embedded in a file
+func mainPictures() {
+	flag.Parse()
+
+	pics, err := tags.ReadPictures(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("error reading pictures: %v", err)
+	}
+	for _, pic := range pics {
+		fmt.Printf("%d %s %dx%d\n", pic.Type, pic.MIMEType, pic.Width, pic.Height)
+	}
+}
+
+// mainFFmpeg stands in for ffmpeg cutting a track from an image, by copying the whole image
+func mainFFmpeg() {
+	input := flag.String("i", "", "")
+	flag.Bool("nostdin", false, "")
+	for _, name := range []string{"loglevel", "ss", "to", "map", "map_metadata", "c:a"} {
+		flag.String(name, "", "")
+	}
+	flag.Parse()
+
+	if err := copyFile(*input, flag.Arg(0)); err != nil {
+		log.Fatalf("error copying: %v", err)
+	}
+}
+
+// mainShnsplit stands in for shnsplit splitting an image into a file per track, by copying the whole image
+func mainShnsplit() {
+	cuePath := flag.String("f", "", "")
+	dir := flag.String("d", "", "")
+	flag.Bool("q", false, "")
+	flag.String("o", "", "")
+	flag.String("t", "", "")
+	flag.Parse()
+
+	sheet, err := cuesheet.ParseFile(*cuePath)
+	if err != nil {
+		log.Fatalf("error parsing cue sheet: %v", err)
+	}
+	// like shnsplit, the files are numbered in order, with any pregap before the first track in "00"
+	tracks := sheet.Tracks()
+	var names []string
+	if start, _ := tracks[0].Start(); start > 0 {
+		names = append(names, "00.flac")
+	}
+	for i := range tracks {
+		names = append(names, fmt.Sprintf("%02d.flac", i+1))
+	}
+	for _, name := range names {
+		if err := copyFile(flag.Arg(0), filepath.Join(*dir, name)); err != nil {
+			log.Fatalf("error copying: %v", err)
+		}
+	}
+}
+
+func copyFile(src, dest string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dest, data, 0o644)
+}
+
+// mainArchive writes the files in a dir to a zip or tar.gz archive, depending on its extension
+func mainArchive() {
+	flag.Parse()
+
+	out, dir := flag.Arg(0), flag.Arg(1)
+	f, err := os.Create(out)
+	if err != nil {
+		log.Fatalf("error creating archive: %v", err)
+	}
+	defer f.Close()
+
+	var add func(name string, data []byte) error
+	switch {
+	case strings.HasSuffix(out, ".zip"):
+		zw := zip.NewWriter(f)
+		defer zw.Close()
+		add = func(name string, data []byte) error {
+			w, err := zw.Create(name)
+			if err != nil {
+				return err
+			}
+			_, err = w.Write(data)
+			return err
+		}
+	case strings.HasSuffix(out, ".tar.gz"):
+		gw := gzip.NewWriter(f)
+		defer gw.Close()
+		tw := tar.NewWriter(gw)
+		defer tw.Close()
+		add = func(name string, data []byte) error {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+				return err
+			}
+			_, err := tw.Write(data)
+			return err
+		}
+	default:
+		log.Fatalf("unknown archive type %q", out)
+	}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		name, _ := filepath.Rel(dir, path)
+		return add(filepath.ToSlash(name), data)
+	})
+	if err != nil {
+		log.Fatalf("error adding files: %v", err)
+	}
+}
+
 func parsePattern(pat string) []string {
 	// assume the file exists if the pattern doesn't look like a glob
 	if fileutil.GlobEscape(pat) == pat {
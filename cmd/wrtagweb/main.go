@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"go.senan.xyz/wrtag"
+	"go.senan.xyz/wrtag/clientutil"
 	"go.senan.xyz/wrtag/cmd/internal/logging"
 	wrtagflag "go.senan.xyz/wrtag/cmd/internal/wrtagflag"
 	"go.senan.xyz/wrtag/researchlink"
@@ -63,14 +64,21 @@ func main() {
 		listenAddr          = flag.String("web-listen-addr", ":7373", "Listen address for web interface (optional)")
 		dbPath              = flag.String("web-db-path", "", "Path to persistent database path for web interface (optional)")
 		publicURL           = flag.String("web-public-url", "", "Public URL for web interface (optional)")
+		overridesConfirm    = flag.Bool("web-overrides-confirm", false, "Allow .wrtag.yaml files to confirm imports without input (optional)")
 	)
 	wrtagflag.Parse()
 
+	cfg.IgnoreOverridesConfirm = !*overridesConfirm
+
 	if cfg.PathFormat.Root() == "" {
 		slog.Error("no path-format configured")
 		return
 	}
 
+	if err := wrtag.RemoveStaleStagingDirs(cfg); err != nil {
+		slog.Error("removing stale staging dirs", "err", err)
+	}
+
 	if *apiKey == "" {
 		slog.Error("need an api key")
 		return
@@ -117,9 +125,17 @@ func main() {
 
 	var sse broadcast[uint64]
 
-	processNextJob := func(ctx context.Context) error {
+	// jobs the user has actioned from the UI are picked up by their own worker, so that their requests can jump ahead
+	// of newly added jobs waiting on the same rate limits
+	processNextJob := func(ctx context.Context, interactive bool) error {
+		priority := clientutil.PriorityBackground
+		if interactive {
+			priority = clientutil.PriorityInteractive
+		}
+		ctx = clientutil.WithPriority(ctx, priority)
+
 		var job Job
-		err := sqlb.ScanRow(ctx, db, &job, "update jobs set status=? where id=(select id from jobs where status=? limit 1) returning *", StatusInProgress, StatusEnqueued)
+		err := sqlb.ScanRow(ctx, db, &job, "update jobs set status=? where id=(select id from jobs where status=? and interactive=? limit 1) returning *", StatusInProgress, StatusEnqueued, interactive)
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil
 		}
@@ -147,7 +163,7 @@ func main() {
 				Artist: searchResult.Query.Artist,
 				Album:  searchResult.Query.Release,
 				UPC:    searchResult.Query.Barcode,
-				Date:   searchResult.Query.Date,
+				Date:   searchResult.Query.Date.Time,
 			})
 			if err != nil {
 				return fmt.Errorf("build links: %w", err)
@@ -157,14 +173,18 @@ func main() {
 		}
 
 		if searchResult != nil && searchResult.Release != nil {
-			job.DestPath, err = wrtag.DestDir(&cfg.PathFormat, searchResult.Release)
-			if err != nil {
-				return fmt.Errorf("gen dest dir: %w", err)
+			job.DestPath = searchResult.DestDir // may be from a path format in the dir's overrides
+			if job.DestPath == "" {
+				job.DestPath, err = wrtag.DestDir(&cfg.PathFormat, searchResult.Release)
+				if err != nil {
+					return fmt.Errorf("gen dest dir: %w", err)
+				}
 			}
 		}
 
 		job.SearchResult = sqlb.NewJSON(searchResult)
 		job.Confirm = false
+		job.Interactive = false
 
 		if processErr != nil {
 			job.Status = StatusError
@@ -329,7 +349,7 @@ func main() {
 		}
 
 		var job Job
-		if err := sqlb.ScanRow(r.Context(), db, &job, "update jobs set confirm=?, use_mbid=?, status=? where id=? and status<>? returning *", confirm, useMBID, StatusEnqueued, id, StatusInProgress); err != nil {
+		if err := sqlb.ScanRow(r.Context(), db, &job, "update jobs set confirm=?, use_mbid=?, interactive=?, status=? where id=? and status<>? returning *", confirm, useMBID, true, StatusEnqueued, id, StatusInProgress); err != nil {
 			respErrf(w, http.StatusInternalServerError, "error getting job")
 			return
 		}
@@ -421,23 +441,25 @@ func main() {
 		return nil
 	})
 
-	errgrp.Go(func() error {
-		defer logJob("process jobs")()
-
-		t := time.NewTicker(1 * time.Second)
-		defer t.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			case <-t.C:
-				if err := processNextJob(ctx); err != nil {
-					return fmt.Errorf("next job: %w", err)
+	for _, interactive := range []bool{false, true} {
+		errgrp.Go(func() error {
+			defer logJob("process jobs", "interactive", interactive)()
+
+			t := time.NewTicker(1 * time.Second)
+			defer t.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-t.C:
+					if err := processNextJob(ctx, interactive); err != nil {
+						return fmt.Errorf("next job: %w", err)
+					}
 				}
 			}
-		}
-	})
+		})
+	}
 
 	if err := errgrp.Wait(); err != nil {
 		slog.Error("wait for jobs", "err", err)
@@ -473,6 +495,7 @@ type Job struct {
 	SearchResult  sqlb.JSON[*wrtag.SearchResult]
 	ResearchLinks sqlb.JSON[[]researchlink.SearchResult]
 	Confirm       bool
+	Interactive   bool
 }
 
 //go:embed schema.sql
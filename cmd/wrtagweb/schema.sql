@@ -26,3 +26,7 @@ alter table jobs
 -- 2024.04.01 add confirm bool --
 alter table jobs
     add column confirm boolean default false;
+
+-- 2025.05.10 add interactive bool --
+alter table jobs
+    add column interactive boolean not null default false;
@@ -2,11 +2,15 @@ package coverparse
 
 import (
 	"cmp"
+	"fmt"
+	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
 	"strconv"
 	"strings"
+
+	"go.senan.xyz/wrtag/imageutil"
 )
 
 func IsCover(p string) bool {
@@ -37,6 +41,84 @@ func BestBetween(cover *string, other string) {
 	}
 }
 
+// Cover is a potential cover, with its dimensions if its header could be decoded.
+type Cover struct {
+	Path          string
+	Size          int64
+	Width, Height int
+}
+
+// Stat reads the size and dimensions of the potential cover at path. Images that can't be decoded are still returned,
+// without dimensions.
+func Stat(path string) (Cover, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Cover{}, fmt.Errorf("stat: %w", err)
+	}
+	c := Cover{Path: path, Size: info.Size()}
+	if config, err := imageutil.DecodeConfigFile(path); err == nil {
+		c.Width, c.Height = config.Width, config.Height
+	}
+	return c, nil
+}
+
+// Pixels returns the number of pixels in the cover, or 0 if its dimensions aren't known.
+func (c Cover) Pixels() int {
+	return c.Width * c.Height
+}
+
+// Square reports whether the cover is roughly square, like a front cover and unlike most scans of booklets or backs.
+func (c Cover) Square() bool {
+	if c.Width == 0 || c.Height == 0 {
+		return false
+	}
+	return float64(min(c.Width, c.Height))/float64(max(c.Width, c.Height)) >= 0.9
+}
+
+// CompareCovers ranks two potential covers, suitable for [slices.SortFunc]. The best art type in their file names is
+// considered first, then whether they're square, their dimensions, and their file size, then the rest of their names
+// like [Compare].
+func CompareCovers(a, b Cover) int {
+	return cmp.Or(
+		cmp.Compare(topArtType(a.Path), topArtType(b.Path)),
+		compareContent(a, b),
+		Compare(a.Path, b.Path),
+	)
+}
+
+// BestCoverBetween updates the current best candidate if the new cover is better.
+func BestCoverBetween(cover *Cover, other Cover) {
+	if cover.Path == "" {
+		*cover = other
+		return
+	}
+	if CompareCovers(*cover, other) > 0 {
+		*cover = other
+	}
+}
+
+func compareContent(a, b Cover) int {
+	if a.Pixels() == 0 || b.Pixels() == 0 {
+		return 0 // couldn't decode one, so only names can be compared
+	}
+	return cmp.Or(
+		-compareBool(a.Square(), b.Square()),
+		-cmp.Compare(a.Pixels(), b.Pixels()),
+		-cmp.Compare(a.Size, b.Size),
+	)
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
 var artTypePriorities = map[string]int{
 	"front":    3,
 	"cover":    3,
@@ -74,6 +156,12 @@ func posArtTypes(path string) []int {
 	return r
 }
 
+// topArtType is the best art type in the file name, or 0 if it has none. Only the name is used, so that the
+// directories the covers are in don't count.
+func topArtType(path string) int {
+	return slices.Min(append(posArtTypes(strings.ToLower(filepath.Base(path))), 0))
+}
+
 var numbersExpr = regexp.MustCompile(`\d+`)
 
 func posNumbers(path string) []int {
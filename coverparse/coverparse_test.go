@@ -182,3 +182,70 @@ func TestCoverSorting(t *testing.T) {
 		})
 	}
 }
+
+func TestCompareCovers(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected []coverparse.Cover
+	}{
+		{
+			name: "larger first with the same art type",
+			expected: []coverparse.Cover{
+				{Path: "front_scan.png", Width: 3000, Height: 3000},
+				{Path: "cover.jpg", Width: 300, Height: 300},
+			},
+		},
+		{
+			name: "square first",
+			expected: []coverparse.Cover{
+				{Path: "scan 2.jpg", Width: 1000, Height: 1000},
+				{Path: "scan 1.jpg", Width: 2000, Height: 1000},
+			},
+		},
+		{
+			name: "file size last",
+			expected: []coverparse.Cover{
+				{Path: "cover 2.jpg", Width: 500, Height: 500, Size: 200},
+				{Path: "cover 1.jpg", Width: 500, Height: 500, Size: 100},
+			},
+		},
+		{
+			name: "art type before content",
+			expected: []coverparse.Cover{
+				{Path: "folder.jpg", Width: 300, Height: 300},
+				{Path: "IMG_0001.jpg", Width: 3000, Height: 3000},
+				{Path: "back.jpg", Width: 3000, Height: 3000},
+			},
+		},
+		{
+			name: "names when not decoded",
+			expected: []coverparse.Cover{
+				{Path: "cover 1.jpg"},
+				{Path: "cover 2.jpg", Width: 3000, Height: 3000},
+			},
+		},
+		{
+			name: "only file names have art types",
+			expected: []coverparse.Cover{
+				{Path: "Artist/Album/cover.jpg", Width: 300, Height: 300},
+				{Path: "Artist/Album/scans/booklet.jpg", Width: 3000, Height: 3000},
+			},
+		},
+	}
+
+	r := rand.New(rand.NewPCG(1, 2))
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			inp := slices.Clone(tc.expected)
+			r.Shuffle(len(inp), func(i, j int) {
+				inp[i], inp[j] = inp[j], inp[i]
+			})
+
+			slices.SortStableFunc(inp, coverparse.CompareCovers)
+
+			if !slices.Equal(inp, tc.expected) {
+				t.Errorf("expected %v got %v", tc.expected, inp)
+			}
+		})
+	}
+}
@@ -0,0 +1,207 @@
+package cuesheet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// https://wiki.hydrogenaud.io/index.php?title=Cue_sheet
+
+// FramesPerSecond is the number of CD frames (sectors) in a second of audio.
+const FramesPerSecond = 75
+
+type Sheet struct {
+	Title     string
+	Performer string
+	Catalog   string
+	Date      string
+	Genre     string
+	Files     []File
+}
+
+type File struct {
+	Name   string
+	Type   string
+	Tracks []Track
+}
+
+type Track struct {
+	Number    int
+	Type      string
+	Title     string
+	Performer string
+	ISRC      string
+	Indexes   []Index
+}
+
+type Index struct {
+	Number int
+	Frames int // offset from the start of the file
+}
+
+// Start returns the frame offset of the track's INDEX 01 in its file.
+func (t Track) Start() (int, bool) {
+	for _, idx := range t.Indexes {
+		if idx.Number == 1 {
+			return idx.Frames, true
+		}
+	}
+	return 0, false
+}
+
+// Tracks returns every track in the sheet regardless of file.
+func (s *Sheet) Tracks() []Track {
+	var tracks []Track
+	for _, f := range s.Files {
+		tracks = append(tracks, f.Tracks...)
+	}
+	return tracks
+}
+
+func ParseFile(path string) (*Sheet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+func Parse(r io.Reader) (*Sheet, error) {
+	r = transform.NewReader(r, unicode.BOMOverride(unicode.UTF8.NewDecoder()))
+
+	var sheet Sheet
+	var file *File
+	var track *Track
+
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields := splitFields(line)
+
+		cmd, args := strings.ToUpper(fields[0]), fields[1:]
+		arg := func(i int) string {
+			if i < len(args) {
+				return args[i]
+			}
+			return ""
+		}
+
+		switch cmd {
+		case "REM":
+			switch strings.ToUpper(arg(0)) {
+			case "DATE":
+				sheet.Date = arg(1)
+			case "GENRE":
+				sheet.Genre = strings.Join(args[1:], " ")
+			}
+		case "CATALOG":
+			sheet.Catalog = arg(0)
+		case "TITLE":
+			if track != nil {
+				track.Title = strings.Join(args, " ")
+			} else {
+				sheet.Title = strings.Join(args, " ")
+			}
+		case "PERFORMER":
+			if track != nil {
+				track.Performer = strings.Join(args, " ")
+			} else {
+				sheet.Performer = strings.Join(args, " ")
+			}
+		case "FILE":
+			sheet.Files = append(sheet.Files, File{Name: arg(0), Type: arg(1)})
+			file, track = &sheet.Files[len(sheet.Files)-1], nil
+		case "TRACK":
+			if file == nil {
+				return nil, fmt.Errorf("line %d: track before file", lineNum)
+			}
+			num, err := strconv.Atoi(arg(0))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: parse track number: %w", lineNum, err)
+			}
+			file.Tracks = append(file.Tracks, Track{Number: num, Type: arg(1)})
+			track = &file.Tracks[len(file.Tracks)-1]
+		case "ISRC":
+			if track != nil {
+				track.ISRC = arg(0)
+			}
+		case "INDEX":
+			if track == nil {
+				return nil, fmt.Errorf("line %d: index before track", lineNum)
+			}
+			num, err := strconv.Atoi(arg(0))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: parse index number: %w", lineNum, err)
+			}
+			frames, err := ParseTimestamp(arg(1))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			track.Indexes = append(track.Indexes, Index{Number: num, Frames: frames})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	return &sheet, nil
+}
+
+// ParseTimestamp parses a mm:ss:ff timestamp into a number of frames.
+func ParseTimestamp(ts string) (int, error) {
+	parts := strings.Split(ts, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", ts)
+	}
+	var mm, ss, ff int
+	var err error
+	if mm, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, fmt.Errorf("parse minutes: %w", err)
+	}
+	if ss, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, fmt.Errorf("parse seconds: %w", err)
+	}
+	if ff, err = strconv.Atoi(parts[2]); err != nil {
+		return 0, fmt.Errorf("parse frames: %w", err)
+	}
+	return (mm*60+ss)*FramesPerSecond + ff, nil
+}
+
+// splitFields splits a line on spaces, keeping double quoted values together. Backslashes are not treated
+// as escapes since they are commonly found in Windows paths.
+func splitFields(line string) []string {
+	var fields []string
+	var cur strings.Builder
+	var inQuote, haveField bool
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			haveField = true
+		case !inQuote && (r == ' ' || r == '\t'):
+			if haveField {
+				fields = append(fields, cur.String())
+				cur.Reset()
+				haveField = false
+			}
+		default:
+			cur.WriteRune(r)
+			haveField = true
+		}
+	}
+	if haveField {
+		fields = append(fields, cur.String())
+	}
+	return fields
+}
@@ -0,0 +1,99 @@
+package cuesheet_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/cuesheet"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	const cue = "\ufeff" + `REM GENRE Detroit Techno
+REM DATE 1997
+REM COMMENT "ExactAudioCopy v1.6"
+CATALOG 0123456789012
+PERFORMER "Jeff Mills"
+TITLE "Kat Moda"
+FILE "C:\Rips\Kat Moda.wav" WAVE
+  TRACK 01 AUDIO
+    TITLE "Alarms"
+    ISRC GBAAA9700001
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "The Bells"
+    PERFORMER "Jeff Mills & Someone"
+    INDEX 00 03:20:00
+    INDEX 01 03:22:63
+FILE "data.bin" BINARY
+  TRACK 03 MODE1/2352
+    INDEX 01 00:00:00
+`
+	sheet, err := cuesheet.Parse(strings.NewReader(cue))
+	require.NoError(t, err)
+
+	assert.Equal(t, &cuesheet.Sheet{
+		Title:     "Kat Moda",
+		Performer: "Jeff Mills",
+		Catalog:   "0123456789012",
+		Date:      "1997",
+		Genre:     "Detroit Techno",
+		Files: []cuesheet.File{
+			{Name: `C:\Rips\Kat Moda.wav`, Type: "WAVE", Tracks: []cuesheet.Track{
+				{Number: 1, Type: "AUDIO", Title: "Alarms", ISRC: "GBAAA9700001", Indexes: []cuesheet.Index{{Number: 1, Frames: 0}}},
+				{Number: 2, Type: "AUDIO", Title: "The Bells", Performer: "Jeff Mills & Someone", Indexes: []cuesheet.Index{{Number: 0, Frames: 15000}, {Number: 1, Frames: 15213}}},
+			}},
+			{Name: "data.bin", Type: "BINARY", Tracks: []cuesheet.Track{
+				{Number: 3, Type: "MODE1/2352", Indexes: []cuesheet.Index{{Number: 1, Frames: 0}}},
+			}},
+		},
+	}, sheet)
+
+	assert.Len(t, sheet.Tracks(), 3)
+	start, ok := sheet.Files[0].Tracks[1].Start()
+	assert.True(t, ok)
+	assert.Equal(t, 15213, start)
+}
+
+func TestParseErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, cue := range []string{
+		"TRACK 01 AUDIO\n",
+		"FILE \"a.wav\" WAVE\n  INDEX 01 00:00:00\n",
+		"FILE \"a.wav\" WAVE\n  TRACK one AUDIO\n",
+		"FILE \"a.wav\" WAVE\n  TRACK 01 AUDIO\n    INDEX 01 00:00\n",
+	} {
+		_, err := cuesheet.Parse(strings.NewReader(cue))
+		assert.Error(t, err, cue)
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		ts     string
+		frames int
+		err    bool
+	}{
+		{ts: "00:00:00", frames: 0},
+		{ts: "00:01:00", frames: 75},
+		{ts: "03:22:63", frames: 15213},
+		{ts: "79:59:74", frames: 359999},
+		{ts: "03:22", err: true},
+		{ts: "03:xx:00", err: true},
+	} {
+		frames, err := cuesheet.ParseTimestamp(tc.ts)
+		if tc.err {
+			assert.Error(t, err, tc.ts)
+			continue
+		}
+		require.NoError(t, err, tc.ts)
+		assert.Equal(t, tc.frames, frames, tc.ts)
+	}
+}
@@ -0,0 +1,267 @@
+// Package cuesplit splits single file CD images into a file per track, using the tracks in a CUE sheet next to them.
+package cuesplit
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go.senan.xyz/wrtag/cuesheet"
+	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/tags"
+)
+
+var ErrNoTool = errors.New("split tool not found in PATH")
+var ErrLossyImage = errors.New("image is lossy")
+
+// Tool is an external command that can split images.
+type Tool uint8
+
+const (
+	FFmpeg Tool = iota
+	Shnsplit
+)
+
+var toolNames = []string{
+	FFmpeg:   "ffmpeg",
+	Shnsplit: "shnsplit",
+}
+
+func ParseTool(s string) (Tool, error) {
+	i := slices.Index(toolNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown split tool %q. expected one of %s", s, strings.Join(toolNames, ", "))
+	}
+	return Tool(i), nil
+}
+
+func (t Tool) String() string {
+	if int(t) < len(toolNames) {
+		return toolNames[t]
+	}
+	return ""
+}
+
+// Image is an audio file with a CUE sheet that lists several tracks in it.
+type Image struct {
+	Path    string
+	CuePath string
+	Sheet   *cuesheet.Sheet
+}
+
+// Find looks for a CUE sheet in dir that lists several tracks in a single audio file, which is also in dir. Sheets
+// naming a file that doesn't exist are matched with an audio file of the same name, since rips are often converted
+// after the sheet is written. It returns nil if there are none.
+func Find(dir string) (*Image, error) {
+	cues, err := fileutil.GlobDir(dir, "*.cue")
+	if err != nil {
+		return nil, fmt.Errorf("glob for cue sheets: %w", err)
+	}
+	for _, p := range cues {
+		sheet, err := cuesheet.ParseFile(p)
+		if err != nil {
+			continue
+		}
+		if len(sheet.Files) != 1 || len(sheet.Files[0].Tracks) < 2 {
+			continue
+		}
+		if slices.ContainsFunc(sheet.Files[0].Tracks, func(t cuesheet.Track) bool { _, ok := t.Start(); return !ok }) {
+			continue
+		}
+		path, err := findAudio(dir, filepath.Base(sheet.Files[0].Name))
+		if err != nil {
+			return nil, err
+		}
+		if path == "" {
+			continue
+		}
+		if !isLossless(path) {
+			// splitting would mean either re-encoding to FLAC or cutting it at the nearest frames
+			return nil, fmt.Errorf("%w: %s", ErrLossyImage, filepath.Base(path))
+		}
+		return &Image{Path: path, CuePath: p, Sheet: sheet}, nil
+	}
+	return nil, nil
+}
+
+func findAudio(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	if _, err := os.Stat(path); err == nil && tags.CanRead(path) {
+		return path, nil
+	}
+	matches, err := fileutil.GlobDir(dir, strings.TrimSuffix(name, filepath.Ext(name))+".*")
+	if err != nil {
+		return "", fmt.Errorf("glob for audio: %w", err)
+	}
+	for _, p := range matches {
+		if tags.CanRead(p) {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// isLossless reports whether path is in a lossless format that can be split into FLAC without losing anything.
+func isLossless(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac", ".wav", ".aiff", ".ape", ".tak", ".wv":
+		return true
+	}
+	return false
+}
+
+// Split splits the image into a FLAC file per track in dest, named by track number. The files are tagged with the
+// image's tags, and the titles, performers, and other details from the sheet. It returns the paths in track order.
+func Split(ctx context.Context, tool Tool, img *Image, dest string) ([]string, error) {
+	if _, err := exec.LookPath(tool.String()); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrNoTool, err)
+	}
+
+	paths, pathTags, err := Tracks(img, dest)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := img.Sheet.Files[0].Tracks
+
+	switch tool {
+	case FFmpeg:
+		for i, t := range tracks {
+			var next *cuesheet.Track
+			if i+1 < len(tracks) {
+				next = &tracks[i+1]
+			}
+			if err := runFFmpeg(ctx, img.Path, t, next, paths[i]); err != nil {
+				return nil, fmt.Errorf("run ffmpeg for track %d: %w", t.Number, err)
+			}
+		}
+	case Shnsplit:
+		if err := runShnsplit(ctx, img, dest, paths); err != nil {
+			return nil, fmt.Errorf("run shnsplit: %w", err)
+		}
+	}
+
+	for i, t := range tracks {
+		if err := tags.ReplaceTags(paths[i], pathTags[i]); err != nil {
+			return nil, fmt.Errorf("write tags for track %d: %w", t.Number, err)
+		}
+	}
+	return paths, nil
+}
+
+// Tracks returns the paths that Split writes the image's tracks to in dest, and their tags, without splitting it.
+func Tracks(img *Image, dest string) ([]string, []tags.Tags, error) {
+	imgTags, err := tags.ReadTags(img.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("read image tags: %w", err)
+	}
+
+	var paths []string
+	var pathTags []tags.Tags
+	for _, t := range img.Sheet.Files[0].Tracks {
+		paths = append(paths, filepath.Join(dest, fmt.Sprintf("%02d.flac", t.Number)))
+		pathTags = append(pathTags, trackTags(imgTags, img.Sheet, t))
+	}
+	return paths, pathTags, nil
+}
+
+// runFFmpeg cuts a track from its INDEX 01 to the next track's, so that any gap before a track is at the end of the
+// one before it, like a CD player.
+func runFFmpeg(ctx context.Context, path string, t cuesheet.Track, next *cuesheet.Track, out string) error {
+	start, _ := t.Start()
+	args := []string{"-nostdin", "-loglevel", "error", "-i", path, "-ss", timestamp(start)}
+	if next != nil {
+		end, _ := next.Start()
+		args = append(args, "-to", timestamp(end))
+	}
+	args = append(args, "-map", "0:a", "-map_metadata", "-1", "-c:a", "flac", out)
+	return run(ctx, FFmpeg, args)
+}
+
+// runShnsplit splits the image into a temporary directory in dest, then renames the tracks to paths. shnsplit numbers
+// its files in order rather than with the sheet's track numbers, and writes any pregap before the first track to an
+// extra first file, which is left behind.
+func runShnsplit(ctx context.Context, img *Image, dest string, paths []string) error {
+	tmp, err := os.MkdirTemp(dest, ".shnsplit-*")
+	if err != nil {
+		return fmt.Errorf("make temp dir: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := run(ctx, Shnsplit, []string{"-q", "-f", img.CuePath, "-d", tmp, "-o", "flac", "-t", "%n", img.Path}); err != nil {
+		return err
+	}
+
+	split, err := fileutil.GlobDir(tmp, "*.flac")
+	if err != nil {
+		return fmt.Errorf("glob for tracks: %w", err)
+	}
+	slices.Sort(split)
+	if len(split) == len(paths)+1 {
+		split = split[1:]
+	}
+	if len(split) != len(paths) {
+		return fmt.Errorf("expected %d tracks, got %d", len(paths), len(split))
+	}
+	for i := range split {
+		if err := os.Rename(split[i], paths[i]); err != nil {
+			return fmt.Errorf("rename track: %w", err)
+		}
+	}
+	return nil
+}
+
+func run(ctx context.Context, tool Tool, args []string) error {
+	cmd := exec.CommandContext(ctx, tool.String(), args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("%w: stderr: %q", err, stderr.String())
+		}
+		return err
+	}
+	return nil
+}
+
+// trackTags is the image's tags with the details of the track, and of the release if the sheet has them. The image's
+// title is usually the release's, so it's replaced even if the track has none.
+func trackTags(imgTags tags.Tags, sheet *cuesheet.Sheet, t cuesheet.Track) tags.Tags {
+	var trackTags tags.Tags
+	for k, vs := range imgTags.Iter() {
+		trackTags.Set(k, vs...)
+	}
+	for k, v := range map[string]string{
+		tags.Album:       sheet.Title,
+		tags.AlbumArtist: sheet.Performer,
+		tags.Artist:      cmp.Or(t.Performer, sheet.Performer),
+		tags.Date:        sheet.Date,
+		tags.Genre:       sheet.Genre,
+		tags.UPC:         sheet.Catalog,
+		tags.ISRC:        t.ISRC,
+	} {
+		if v != "" {
+			trackTags.Set(k, v)
+		}
+	}
+	trackTags.Set(tags.Title)
+	if t.Title != "" {
+		trackTags.Set(tags.Title, t.Title)
+	}
+	trackTags.Set(tags.TrackNumber, strconv.Itoa(t.Number))
+	return trackTags
+}
+
+func timestamp(frames int) string {
+	return strconv.FormatFloat(float64(frames)/cuesheet.FramesPerSecond, 'f', 6, 64)
+}
@@ -0,0 +1,114 @@
+package cuesplit_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/cuesplit"
+)
+
+const imageCue = `PERFORMER "Jeff Mills"
+TITLE "Kat Moda"
+FILE "Kat Moda.wav" WAVE
+  TRACK 01 AUDIO
+    TITLE "Alarms"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "The Bells"
+    INDEX 00 03:20:00
+    INDEX 01 03:22:63
+`
+
+const tracksCue = `FILE "01.flac" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+FILE "02.flac" WAVE
+  TRACK 02 AUDIO
+    INDEX 01 00:00:00
+`
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("image", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"Kat Moda.cue": imageCue, "Kat Moda.wav": ""})
+
+		img, err := cuesplit.Find(dir)
+		require.NoError(t, err)
+		require.NotNil(t, img)
+		assert.Equal(t, filepath.Join(dir, "Kat Moda.wav"), img.Path)
+		assert.Equal(t, filepath.Join(dir, "Kat Moda.cue"), img.CuePath)
+		assert.Equal(t, "Kat Moda", img.Sheet.Title)
+		assert.Len(t, img.Sheet.Tracks(), 2)
+	})
+
+	t.Run("converted image", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"Kat Moda.cue": imageCue, "Kat Moda.log": "", "Kat Moda.flac": ""})
+
+		img, err := cuesplit.Find(dir)
+		require.NoError(t, err)
+		require.NotNil(t, img)
+		assert.Equal(t, filepath.Join(dir, "Kat Moda.flac"), img.Path)
+	})
+
+	t.Run("lossy image", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"Kat Moda.cue": imageCue, "Kat Moda.mp3": ""})
+
+		_, err := cuesplit.Find(dir)
+		assert.ErrorIs(t, err, cuesplit.ErrLossyImage)
+	})
+
+	t.Run("missing image", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"Kat Moda.cue": imageCue})
+
+		img, err := cuesplit.Find(dir)
+		require.NoError(t, err)
+		assert.Nil(t, img)
+	})
+
+	t.Run("file per track", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		writeFiles(t, dir, map[string]string{"Kat Moda.cue": tracksCue, "01.flac": "", "02.flac": ""})
+
+		img, err := cuesplit.Find(dir)
+		require.NoError(t, err)
+		assert.Nil(t, img)
+	})
+}
+
+func TestParseTool(t *testing.T) {
+	t.Parallel()
+
+	tool, err := cuesplit.ParseTool("shnsplit")
+	require.NoError(t, err)
+	assert.Equal(t, cuesplit.Shnsplit, tool)
+
+	_, err = cuesplit.ParseTool("sox")
+	assert.Error(t, err)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+	}
+}
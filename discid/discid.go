@@ -0,0 +1,235 @@
+package discid
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+
+	"go.senan.xyz/wrtag/cuesheet"
+	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/tags"
+)
+
+// https://musicbrainz.org/doc/Disc_ID_Calculation
+
+var ErrNoTOC = errors.New("no toc found")
+
+// leadIn is the number of sectors before the first track on every CD.
+const leadIn = 150
+
+// sessionGap is the number of sectors between the audio session of an enhanced CD and the data track after it.
+// Disc IDs end at the audio session, so its lead-out is this far before the data track.
+const sessionGap = 11400
+
+const maxTracks = 99
+
+// TOC is a CD table of contents. All offsets are in sectors and include the lead-in.
+type TOC struct {
+	FirstTrack int
+	LastTrack  int
+	LeadOut    int
+	Offsets    []int
+}
+
+// ID computes the MusicBrainz Disc ID for the TOC.
+func (t TOC) ID() string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%02X", t.FirstTrack)
+	fmt.Fprintf(h, "%02X", t.LastTrack)
+	fmt.Fprintf(h, "%08X", t.LeadOut)
+	for i := range maxTracks {
+		var offset int
+		if i < len(t.Offsets) {
+			offset = t.Offsets[i]
+		}
+		fmt.Fprintf(h, "%08X", offset)
+	}
+	return discIDEncoding.EncodeToString(h.Sum(nil))
+}
+
+// String formats the TOC like the MusicBrainz web service toc parameter, with values separated by spaces.
+func (t TOC) String() string {
+	parts := []string{strconv.Itoa(t.FirstTrack), strconv.Itoa(t.LastTrack), strconv.Itoa(t.LeadOut)}
+	for _, o := range t.Offsets {
+		parts = append(parts, strconv.Itoa(o))
+	}
+	return strings.Join(parts, " ")
+}
+
+func (t TOC) validate() error {
+	if len(t.Offsets) == 0 || len(t.Offsets) > maxTracks {
+		return fmt.Errorf("invalid track count %d", len(t.Offsets))
+	}
+	if t.LastTrack-t.FirstTrack+1 != len(t.Offsets) {
+		return fmt.Errorf("track numbers %d-%d don't match offset count %d", t.FirstTrack, t.LastTrack, len(t.Offsets))
+	}
+	for i, o := range t.Offsets {
+		if o >= t.LeadOut || (i > 0 && o <= t.Offsets[i-1]) {
+			return fmt.Errorf("offsets out of order")
+		}
+	}
+	return nil
+}
+
+var discIDEncoding = base64.NewEncoding("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789._").WithPadding('-')
+
+// Find looks for a rip log or CUE sheet in dir and returns the TOC from the first one that has one. Logs
+// are preferred since they list exact sectors, whereas CUE sheets can only be combined with track lengths.
+// Files that don't contain a usable TOC are skipped, and nil is returned if there are none.
+func Find(dir string) (*TOC, error) {
+	logs, err := fileutil.GlobDir(dir, "*.log")
+	if err != nil {
+		return nil, fmt.Errorf("glob for logs: %w", err)
+	}
+	for _, p := range logs {
+		toc, err := parseLogFile(p)
+		if err != nil {
+			continue
+		}
+		return &toc, nil
+	}
+
+	cues, err := fileutil.GlobDir(dir, "*.cue")
+	if err != nil {
+		return nil, fmt.Errorf("glob for cue sheets: %w", err)
+	}
+	for _, p := range cues {
+		sheet, err := cuesheet.ParseFile(p)
+		if err != nil {
+			continue
+		}
+		toc, err := FromCueSheet(sheet, func(name string) (int, error) {
+			return fileSectors(filepath.Join(dir, filepath.Base(name)))
+		})
+		if err != nil {
+			continue
+		}
+		return &toc, nil
+	}
+	return nil, nil
+}
+
+func parseLogFile(path string) (TOC, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return TOC{}, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	return ParseLog(f)
+}
+
+// matches rows like "    1  |  0:00.00 |  5:32.14 |         0    |    24913   " in EAC and XLD logs
+var logTOCRowExpr = regexp.MustCompile(`^\s*(\d+)\s*\|\s*[\d:.]+\s*\|\s*[\d:.]+\s*\|\s*(\d+)\s*\|\s*(\d+)\s*$`)
+
+// ParseLog reads the TOC from an EAC or XLD rip log. Logs may be UTF-16 (EAC) or UTF-8 (XLD). The data track of an
+// enhanced CD is left out, since it's after the session gap.
+func ParseLog(r io.Reader) (TOC, error) {
+	r = transform.NewReader(r, unicode.BOMOverride(unicode.UTF8.NewDecoder()))
+
+	var toc TOC
+	var lastEnd int
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		m := logTOCRowExpr.FindStringSubmatch(sc.Text())
+		if m == nil {
+			if len(toc.Offsets) > 0 {
+				break // only the first table
+			}
+			continue
+		}
+		num, _ := strconv.Atoi(m[1])
+		start, _ := strconv.Atoi(m[2])
+		end, _ := strconv.Atoi(m[3])
+		if len(toc.Offsets) > 0 && start-(lastEnd+1) == sessionGap {
+			break // the data session
+		}
+		if len(toc.Offsets) == 0 {
+			toc.FirstTrack = num
+		}
+		toc.LastTrack = num
+		toc.Offsets = append(toc.Offsets, start+leadIn)
+		lastEnd = end
+	}
+	if err := sc.Err(); err != nil {
+		return TOC{}, fmt.Errorf("scan: %w", err)
+	}
+	if len(toc.Offsets) == 0 {
+		return TOC{}, ErrNoTOC
+	}
+	toc.LeadOut = lastEnd + 1 + leadIn
+	if err := toc.validate(); err != nil {
+		return TOC{}, fmt.Errorf("validate: %w", err)
+	}
+	return toc, nil
+}
+
+// FromCueSheet builds a TOC from the tracks in a CUE sheet. Since CUE sheets only give offsets relative
+// to their files, fileSectors is used to find the length of each referenced file. A data track after the audio
+// tracks, like on an enhanced CD, ends the TOC. One before them, like on a mixed mode CD, is counted as a track.
+func FromCueSheet(sheet *cuesheet.Sheet, fileSectors func(name string) (int, error)) (TOC, error) {
+	var toc TOC
+	var fileStart int
+	var hasAudio bool
+files:
+	for _, f := range sheet.Files {
+		for i, t := range f.Tracks {
+			start, ok := t.Start()
+			if !ok {
+				return TOC{}, fmt.Errorf("track %d has no index 01", t.Number)
+			}
+			isAudio := t.Type == "" || strings.EqualFold(t.Type, "AUDIO")
+			if !isAudio && hasAudio {
+				toc.LeadOut = fileStart + leadIn
+				if i > 0 {
+					// an image of the whole disc has the session gap in it
+					toc.LeadOut += start - sessionGap
+				}
+				break files
+			}
+			hasAudio = hasAudio || isAudio
+			if len(toc.Offsets) == 0 {
+				toc.FirstTrack = t.Number
+			}
+			toc.LastTrack = t.Number
+			toc.Offsets = append(toc.Offsets, fileStart+start+leadIn)
+		}
+		sectors, err := fileSectors(f.Name)
+		if err != nil {
+			return TOC{}, fmt.Errorf("get length of %q: %w", f.Name, err)
+		}
+		fileStart += sectors
+		toc.LeadOut = fileStart + leadIn
+	}
+	if !hasAudio {
+		return TOC{}, ErrNoTOC
+	}
+	if err := toc.validate(); err != nil {
+		return TOC{}, fmt.Errorf("validate: %w", err)
+	}
+	return toc, nil
+}
+
+func fileSectors(path string) (int, error) {
+	// cue sheets often name the file they were ripped to, which may have been converted since
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	props, err := tags.ReadProperties(path)
+	if err != nil {
+		return 0, fmt.Errorf("read properties: %w", err)
+	}
+	return int(math.Round(props.Length.Seconds() * cuesheet.FramesPerSecond)), nil
+}
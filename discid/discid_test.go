@@ -0,0 +1,172 @@
+package discid_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.senan.xyz/wrtag/cuesheet"
+	"go.senan.xyz/wrtag/discid"
+)
+
+func TestID(t *testing.T) {
+	t.Parallel()
+
+	// from libdiscid's test suite
+	toc := discid.TOC{
+		FirstTrack: 1,
+		LastTrack:  6,
+		LeadOut:    95462,
+		Offsets:    []int{150, 15363, 32314, 46592, 63414, 80489},
+	}
+	assert.Equal(t, "49HHV7Eb8UKF3aQiNmu1GR8vKTY-", toc.ID())
+	assert.Equal(t, "1 6 95462 150 15363 32314 46592 63414 80489", toc.String())
+}
+
+func TestParseLog(t *testing.T) {
+	t.Parallel()
+
+	const log = `
+Exact Audio Copy V1.0 beta 3 from 29. August 2011
+
+TOC of the extracted CD
+
+     Track |   Start  |  Length  | Start sector | End sector
+    ---------------------------------------------------------
+        1  |  0:00.00 |  3:22.38 |         0    |    15212
+        2  |  3:22.38 |  3:46.01 |     15213    |    32163
+        3  |  6:48.39 |  3:10.28 |     32164    |    46441
+
+Range status and errors
+`
+	toc, err := discid.ParseLog(strings.NewReader(log))
+	require.NoError(t, err)
+	assert.Equal(t, discid.TOC{FirstTrack: 1, LastTrack: 3, LeadOut: 46592, Offsets: []int{150, 15363, 32314}}, toc)
+
+	_, err = discid.ParseLog(strings.NewReader("no toc here"))
+	assert.ErrorIs(t, err, discid.ErrNoTOC)
+}
+
+func TestFromCueSheet(t *testing.T) {
+	t.Parallel()
+
+	const cue = `
+PERFORMER "Artist"
+TITLE "Album"
+FILE "image.flac" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    INDEX 00 03:20:00
+    INDEX 01 03:22:63
+  TRACK 03 AUDIO
+    INDEX 01 07:08:64
+`
+	sheet, err := cuesheet.Parse(strings.NewReader(cue))
+	require.NoError(t, err)
+
+	toc, err := discid.FromCueSheet(sheet, func(name string) (int, error) {
+		assert.Equal(t, "image.flac", name)
+		return 46442, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, discid.TOC{FirstTrack: 1, LastTrack: 3, LeadOut: 46592, Offsets: []int{150, 15363, 32314}}, toc)
+}
+
+// the disc from libdiscid's test suite, as track starts and lengths in sectors without the lead-in
+var (
+	knownID      = "49HHV7Eb8UKF3aQiNmu1GR8vKTY-"
+	knownStarts  = []int{0, 15213, 32164, 46442, 63264, 80339}
+	knownLengths = []int{15213, 16951, 14278, 16822, 17075, 14973}
+	knownSectors = 95312
+)
+
+func TestFromCueSheetIDs(t *testing.T) {
+	t.Parallel()
+
+	msf := func(frames int) string {
+		return fmt.Sprintf("%02d:%02d:%02d", frames/75/60, frames/75%60, frames%75)
+	}
+
+	var singleFile, fileEach strings.Builder
+	singleFile.WriteString("FILE \"image.flac\" WAVE\n")
+	fileSectors := map[string]int{"image.flac": knownSectors, "disc.bin": knownSectors + 11400 + 5000}
+	for i, start := range knownStarts {
+		fmt.Fprintf(&singleFile, "  TRACK %02d AUDIO\n    INDEX 01 %s\n", i+1, msf(start))
+
+		name := fmt.Sprintf("%02d.flac", i+1)
+		fmt.Fprintf(&fileEach, "FILE %q WAVE\n  TRACK %02d AUDIO\n    INDEX 01 00:00:00\n", name, i+1)
+		fileSectors[name] = knownLengths[i]
+	}
+
+	tcases := []struct {
+		name string
+		cue  string
+		id   string
+	}{
+		{"single file", singleFile.String(), knownID},
+		{"file per track", fileEach.String(), knownID},
+		{"enhanced cd with the data track in its own file", singleFile.String() + "FILE \"data.bin\" BINARY\n  TRACK 07 MODE1/2352\n    INDEX 01 00:00:00\n", knownID},
+		{"enhanced cd imaged with the session gap", strings.Replace(singleFile.String(), "image.flac", "disc.bin", 1) + fmt.Sprintf("  TRACK 07 MODE1/2352\n    INDEX 01 %s\n", msf(knownSectors+11400)), knownID},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			sheet, err := cuesheet.Parse(strings.NewReader(tc.cue))
+			require.NoError(t, err)
+
+			toc, err := discid.FromCueSheet(sheet, func(name string) (int, error) {
+				sectors, ok := fileSectors[name]
+				if !ok {
+					return 0, fmt.Errorf("no length for %q", name)
+				}
+				return sectors, nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.id, toc.ID())
+		})
+	}
+}
+
+func TestFromCueSheetMixedMode(t *testing.T) {
+	t.Parallel()
+
+	const cue = `
+FILE "data.bin" BINARY
+  TRACK 01 MODE1/2352
+    INDEX 01 00:00:00
+FILE "audio.flac" WAVE
+  TRACK 02 AUDIO
+    INDEX 01 00:00:00
+  TRACK 03 AUDIO
+    INDEX 01 01:00:00
+`
+	sheet, err := cuesheet.Parse(strings.NewReader(cue))
+	require.NoError(t, err)
+
+	toc, err := discid.FromCueSheet(sheet, func(name string) (int, error) {
+		return map[string]int{"data.bin": 1000, "audio.flac": 9000}[name], nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, discid.TOC{FirstTrack: 1, LastTrack: 3, LeadOut: 10150, Offsets: []int{150, 1150, 5650}}, toc)
+}
+
+func TestParseLogEnhanced(t *testing.T) {
+	t.Parallel()
+
+	var log strings.Builder
+	log.WriteString("TOC of the extracted CD\n\n     Track |   Start  |  Length  | Start sector | End sector\n")
+	for i, start := range knownStarts {
+		fmt.Fprintf(&log, "        %d  |  0:00.00 |  0:00.00 |    %6d    |    %6d\n", i+1, start, start+knownLengths[i]-1)
+	}
+	dataStart := knownSectors + 11400
+	fmt.Fprintf(&log, "        7  |  0:00.00 |  0:00.00 |    %6d    |    %6d\n", dataStart, dataStart+5000)
+
+	toc, err := discid.ParseLog(strings.NewReader(log.String()))
+	require.NoError(t, err)
+	assert.Equal(t, 6, toc.LastTrack)
+	assert.Equal(t, knownID, toc.ID())
+}
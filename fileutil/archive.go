@@ -0,0 +1,187 @@
+package fileutil
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var archiveExts = []string{".zip", ".tar", ".tar.gz", ".tgz"}
+
+// IsArchive reports whether the path has the extension of an archive that [ExtractArchive] can extract.
+func IsArchive(path string) bool {
+	return ArchiveExt(path) != ""
+}
+
+// ArchiveExt returns the archive extension of the path, like ".tar.gz", or an empty string if it isn't an archive.
+func ArchiveExt(path string) string {
+	lower := strings.ToLower(path)
+	for _, ext := range archiveExts {
+		if strings.HasSuffix(lower, ext) {
+			return path[len(path)-len(ext):]
+		}
+	}
+	return ""
+}
+
+// ErrArchiveTooLarge is returned by [ExtractArchive] when an archive would extract to more than its limits.
+var ErrArchiveTooLarge = errors.New("archive too large")
+
+// maxArchiveEntries is the most entries an archive can have, so that one can't fill the disk with empty files.
+const maxArchiveEntries = 10_000
+
+// ExtractArchive extracts the regular files and directories in a zip or tar archive into dest. Other entries, like
+// symlinks, are skipped, and so are entries with paths outside dest. If the files would add up to more than maxBytes,
+// or there are too many entries, it stops with ErrArchiveTooLarge and what was extracted so far is left in dest.
+func ExtractArchive(path, dest string, maxBytes int64) error {
+	x := &extractor{dest: dest, remaining: maxBytes}
+	switch ext := strings.ToLower(ArchiveExt(path)); ext {
+	case ".zip":
+		return x.extractZip(path)
+	case ".tar", ".tar.gz", ".tgz":
+		return x.extractTar(path, ext != ".tar")
+	default:
+		return fmt.Errorf("unknown archive type %q", filepath.Ext(path))
+	}
+}
+
+// extractor extracts entries into dest, keeping track of how much more it can extract.
+type extractor struct {
+	dest      string
+	remaining int64
+	entries   int
+}
+
+func (x *extractor) extractZip(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("open zip: %w", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if err := x.extractZipFile(f); err != nil {
+			return fmt.Errorf("extract %q: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+func (x *extractor) extractZipFile(f *zip.File) error {
+	if f.Mode().IsDir() {
+		return x.extractDir(f.Name)
+	}
+	if !f.Mode().IsRegular() {
+		return nil
+	}
+	if f.UncompressedSize64 > uint64(max(x.remaining, 0)) {
+		return ErrArchiveTooLarge // try to avoid decompressing, though the size could be a lie
+	}
+	r, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer r.Close()
+	return x.extractFile(f.Name, r)
+}
+
+func (x *extractor) extractTar(path string, gzipped bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open tar: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipped {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("open gzip: %w", err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = x.extractDir(header.Name)
+		case tar.TypeReg:
+			err = x.extractFile(header.Name, tr)
+		}
+		if err != nil {
+			return fmt.Errorf("extract %q: %w", header.Name, err)
+		}
+	}
+}
+
+func (x *extractor) extractDir(name string) error {
+	path := archivePath(x.dest, name)
+	if path == "" {
+		return nil
+	}
+	if err := x.count(); err != nil {
+		return err
+	}
+	return os.MkdirAll(path, 0o755)
+}
+
+func (x *extractor) extractFile(name string, r io.Reader) error {
+	path := archivePath(x.dest, name)
+	if path == "" {
+		return nil
+	}
+	if err := x.count(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("make parent: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return fmt.Errorf("create: %w", err)
+	}
+	n, err := io.Copy(f, io.LimitReader(r, x.remaining+1))
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("write: %w", err)
+	}
+	if x.remaining -= n; x.remaining < 0 {
+		f.Close()
+		return ErrArchiveTooLarge
+	}
+	return f.Close()
+}
+
+func (x *extractor) count() error {
+	if x.entries++; x.entries > maxArchiveEntries {
+		return ErrArchiveTooLarge
+	}
+	return nil
+}
+
+// archivePath returns where an entry in an archive should be extracted to, or an empty path if it shouldn't be.
+func archivePath(dest, name string) string {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return "" // like "../x" or "/x"
+	}
+	if strings.HasPrefix(filepath.Base(name), "._") || strings.Contains(name, "__MACOSX") {
+		return "" // resource forks from macOS
+	}
+	return filepath.Join(dest, name)
+}
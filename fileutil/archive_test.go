@@ -0,0 +1,146 @@
+package fileutil_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.senan.xyz/wrtag/fileutil"
+)
+
+func TestIsArchive(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, fileutil.IsArchive("/a/Artist - Album.zip"))
+	assert.True(t, fileutil.IsArchive("/a/b.TAR.GZ"))
+	assert.True(t, fileutil.IsArchive("/a/b.tgz"))
+	assert.False(t, fileutil.IsArchive("/a/b.gz"))
+	assert.False(t, fileutil.IsArchive("/a/Artist - Album"))
+	assert.Equal(t, ".TAR.GZ", fileutil.ArchiveExt("/a/b.TAR.GZ"))
+}
+
+func TestExtractZip(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{"album/01.flac", "album/cover.jpg", "../escape", "/abs", "__MACOSX/album/._01.flac"} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(name))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "release.zip")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, fileutil.ExtractArchive(path, dest, 1<<20))
+	assert.Equal(t, []string{"album/01.flac", "album/cover.jpg"}, listFiles(t, dest))
+	assert.NoFileExists(t, filepath.Join(dir, "escape"))
+}
+
+func TestExtractTarGz(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "disc 1/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "disc 1/01.flac", Typeflag: tar.TypeReg, Mode: 0o644, Size: 4}))
+	_, err := tw.Write([]byte("flac"))
+	require.NoError(t, err)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"}))
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "release.tar.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	dest := filepath.Join(dir, "dest")
+	require.NoError(t, fileutil.ExtractArchive(path, dest, 1<<20))
+	assert.Equal(t, []string{"disc 1/01.flac"}, listFiles(t, dest))
+
+	data, err := os.ReadFile(filepath.Join(dest, "disc 1", "01.flac"))
+	require.NoError(t, err)
+	assert.Equal(t, "flac", string(data))
+}
+
+func TestExtractArchiveLimits(t *testing.T) {
+	t.Parallel()
+
+	writeTar := func(t *testing.T, files map[string]int) string {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		for name, size := range files {
+			require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(size)}))
+			_, err := tw.Write(bytes.Repeat([]byte{0}, size))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+
+		path := filepath.Join(t.TempDir(), "release.tar")
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+		return path
+	}
+
+	t.Run("size", func(t *testing.T) {
+		t.Parallel()
+
+		path := writeTar(t, map[string]int{"01.flac": 30, "02.flac": 30})
+		require.NoError(t, fileutil.ExtractArchive(path, t.TempDir(), 60))
+		assert.ErrorIs(t, fileutil.ExtractArchive(path, t.TempDir(), 59), fileutil.ErrArchiveTooLarge)
+	})
+
+	t.Run("zip size", func(t *testing.T) {
+		t.Parallel()
+
+		var buf bytes.Buffer
+		zw := zip.NewWriter(&buf)
+		w, err := zw.Create("01.flac")
+		require.NoError(t, err)
+		_, err = w.Write(bytes.Repeat([]byte{0}, 1<<20)) // compresses to almost nothing
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+
+		path := filepath.Join(t.TempDir(), "release.zip")
+		require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+		assert.ErrorIs(t, fileutil.ExtractArchive(path, t.TempDir(), 1<<10), fileutil.ErrArchiveTooLarge)
+	})
+
+	t.Run("entries", func(t *testing.T) {
+		t.Parallel()
+
+		files := map[string]int{}
+		for i := range 10_001 {
+			files[fmt.Sprintf("%05d", i)] = 0
+		}
+		path := writeTar(t, files)
+		assert.ErrorIs(t, fileutil.ExtractArchive(path, t.TempDir(), 1<<20), fileutil.ErrArchiveTooLarge)
+	})
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var files []string
+	require.NoError(t, filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	}))
+	return files
+}
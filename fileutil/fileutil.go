@@ -1,6 +1,7 @@
 package fileutil
 
 import (
+	"errors"
 	"io/fs"
 	"path/filepath"
 	"strings"
@@ -63,10 +64,19 @@ func normUnidecode(text string) string {
 	return text
 }
 
-func WalkLeaves(root string, fn func(path string, d fs.DirEntry) error) error {
+// SkipPart can be returned by a WalkLeaves skip func to leave out a directory that's part of its parent, like a
+// release's scans. Unlike with filepath.SkipDir, the parent can still be a leaf when it's the root.
+var SkipPart = errors.New("skip part of parent")
+
+// WalkLeaves calls fn for each directory under root without subdirectories. Directories that skip returns
+// filepath.SkipDir or SkipPart for are left out along with everything in them, so a directory with only skipped
+// subdirectories is a leaf too, unless it's the root and any were skipped with filepath.SkipDir. Other errors from
+// skip stop the walk. skip may be nil.
+func WalkLeaves(root string, skip func(path string, d fs.DirEntry) error, fn func(path string, d fs.DirEntry) error) error {
 	var lastDepth int
 	var lastPath string
 	var lastDirEntry fs.DirEntry
+	var skippedInRoot bool
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -74,6 +84,19 @@ func WalkLeaves(root string, fn func(path string, d fs.DirEntry) error) error {
 		if !d.IsDir() {
 			return nil
 		}
+		if skip != nil {
+			switch err := skip(path, d); {
+			case errors.Is(err, filepath.SkipDir):
+				if filepath.Dir(filepath.Clean(path)) == filepath.Clean(root) {
+					skippedInRoot = true
+				}
+				return filepath.SkipDir
+			case errors.Is(err, SkipPart):
+				return filepath.SkipDir
+			case err != nil:
+				return err
+			}
+		}
 		path = filepath.Clean(path)
 		depth := strings.Count(path, string(filepath.Separator))
 		var ferr error
@@ -86,6 +109,10 @@ func WalkLeaves(root string, fn func(path string, d fs.DirEntry) error) error {
 	if err != nil {
 		return err
 	}
+	if lastPath == filepath.Clean(root) && skippedInRoot {
+		// the root only had skipped dirs, so it's where they are rather than a release
+		return nil
+	}
 	if lastPath != "" {
 		return fn(lastPath, lastDirEntry)
 	}
@@ -2,7 +2,9 @@ package fileutil_test
 
 import (
 	"io/fs"
+	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,7 +31,7 @@ func TestWalkLeaves(t *testing.T) {
 	t.Parallel()
 
 	var act []string
-	require.NoError(t, fileutil.WalkLeaves("testdata/leaves", func(path string, d fs.DirEntry) error {
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves", nil, func(path string, d fs.DirEntry) error {
 		act = append(act, path)
 		return nil
 	}))
@@ -50,3 +52,75 @@ func TestWalkLeaves(t *testing.T) {
 	sort.Strings(exp)
 	require.Equal(t, exp, act)
 }
+
+func TestWalkLeavesSkip(t *testing.T) {
+	t.Parallel()
+
+	var act []string
+	skip := func(path string, d fs.DirEntry) error {
+		if strings.HasPrefix(d.Name(), "leaf-") || path == "testdata/leaves/b/a/b/c" {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves", skip, func(path string, d fs.DirEntry) error {
+		act = append(act, path)
+		return nil
+	}))
+
+	exp := []string{
+		"testdata/leaves/a/b/b/c/leaf",
+		"testdata/leaves/a/d/b/c", // only had skipped dirs
+		"testdata/leaves/b/a/b/leaf",
+		"testdata/leaves/b/d/b/c/leaf",
+	}
+
+	sort.Strings(act)
+	require.Equal(t, exp, act)
+}
+
+func TestWalkLeavesSkipRoot(t *testing.T) {
+	t.Parallel()
+
+	var act []string
+	skip := func(path string, d fs.DirEntry) error {
+		if strings.HasPrefix(d.Name(), "leaf-") {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves/a/d/b/c", skip, func(path string, d fs.DirEntry) error {
+		act = append(act, path)
+		return nil
+	}))
+	require.Empty(t, act) // the root only had skipped dirs
+
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves/a/d/b/c/leaf-a", skip, func(path string, d fs.DirEntry) error {
+		act = append(act, path)
+		return nil
+	}))
+	require.Empty(t, act) // the root itself is skipped
+
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves/a/b/b/c/leaf", skip, func(path string, d fs.DirEntry) error {
+		act = append(act, path)
+		return nil
+	}))
+	require.Equal(t, []string{"testdata/leaves/a/b/b/c/leaf"}, act)
+}
+
+func TestWalkLeavesSkipPart(t *testing.T) {
+	t.Parallel()
+
+	var act []string
+	skip := func(path string, d fs.DirEntry) error {
+		if strings.HasPrefix(d.Name(), "leaf-") {
+			return fileutil.SkipPart
+		}
+		return nil
+	}
+	require.NoError(t, fileutil.WalkLeaves("testdata/leaves/a/d/b/c", skip, func(path string, d fs.DirEntry) error {
+		act = append(act, path)
+		return nil
+	}))
+	require.Equal(t, []string{"testdata/leaves/a/d/b/c"}, act) // the skipped dirs are part of the root
+}
@@ -0,0 +1,208 @@
+// Package ignore matches paths against gitignore-style patterns, from .wrtagignore files and the ignore option.
+package ignore
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FileName is the name of a file with patterns for paths to ignore in its directory and below.
+const FileName = ".wrtagignore"
+
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// Pattern is a gitignore-style pattern. Patterns with a slash before the end match paths from the directory they're
+// for, and others match names at any depth. A leading "!" unignores paths, and a trailing slash only matches directories.
+type Pattern struct {
+	str      string
+	expr     *regexp.Regexp
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+func ParsePattern(str string) (Pattern, error) {
+	p := Pattern{str: str}
+	switch {
+	case strings.HasPrefix(str, "!"):
+		p.negate, str = true, str[1:]
+	case strings.HasPrefix(str, `\!`), strings.HasPrefix(str, `\#`):
+		str = str[1:]
+	}
+	if strings.HasSuffix(str, "/") {
+		p.dirOnly, str = true, strings.TrimRight(str, "/")
+	}
+	if str == "" {
+		return Pattern{}, fmt.Errorf("%w: empty pattern %q", ErrInvalidPattern, p.str)
+	}
+	p.anchored = strings.Contains(str, "/")
+
+	expr, err := regexp.Compile("^" + globExpr(strings.TrimPrefix(str, "/")) + "$")
+	if err != nil {
+		return Pattern{}, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	p.expr = expr
+	return p, nil
+}
+
+func (p Pattern) String() string {
+	return p.str
+}
+
+// match matches a slash separated path relative to the directory the pattern is for. Anchored patterns can be
+// matched anywhere too, against the end of the path.
+func (p Pattern) match(rel string, isDir bool, anywhere bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		return p.expr.MatchString(path.Base(rel))
+	}
+	for {
+		if p.expr.MatchString(rel) {
+			return true
+		}
+		_, after, ok := strings.Cut(rel, "/")
+		if !anywhere || !ok {
+			return false
+		}
+		rel = after
+	}
+}
+
+// Parse parses a pattern per line, skipping blank lines and comments starting with "#".
+func Parse(r io.Reader) ([]Pattern, error) {
+	var patterns []Pattern
+	sc := bufio.NewScanner(r)
+	for lineNum := 1; sc.Scan(); lineNum++ {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p, err := ParsePattern(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		patterns = append(patterns, p)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	return patterns, nil
+}
+
+// Matcher matches paths against the patterns from the ignore option and any .wrtagignore files. The last pattern that
+// matches a path decides whether it's ignored, with files in lower directories coming last, so they can unignore paths.
+type Matcher struct {
+	rules []rules
+}
+
+type rules struct {
+	dir      string
+	patterns []Pattern
+	anywhere bool
+}
+
+// New returns a matcher for paths in dir. The patterns match anywhere under dir, and are followed by the patterns in
+// any .wrtagignore files in dir and its parents.
+func New(dir string, patterns []Pattern) (Matcher, error) {
+	dir = filepath.Clean(dir)
+
+	var m Matcher
+	if len(patterns) > 0 {
+		m.rules = append(m.rules, rules{dir: dir, patterns: patterns, anywhere: true})
+	}
+
+	dirs := []string{dir}
+	for d := dir; filepath.Dir(d) != d; d = filepath.Dir(d) {
+		dirs = append(dirs, filepath.Dir(d))
+	}
+	for _, d := range slices.Backward(dirs) {
+		var err error
+		if m, err = m.WithFile(d); err != nil {
+			return Matcher{}, err
+		}
+	}
+	return m, nil
+}
+
+// WithFile returns the matcher with the patterns from the .wrtagignore file in dir added, if there is one.
+func (m Matcher) WithFile(dir string) (Matcher, error) {
+	f, err := os.Open(filepath.Join(dir, FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return Matcher{}, fmt.Errorf("open ignore file: %w", err)
+	}
+	defer f.Close()
+
+	patterns, err := Parse(f)
+	if err != nil {
+		return Matcher{}, fmt.Errorf("parse %q: %w", f.Name(), err)
+	}
+	if len(patterns) == 0 {
+		return m, nil
+	}
+	return Matcher{rules: append(slices.Clip(m.rules), rules{dir: filepath.Clean(dir), patterns: patterns})}, nil
+}
+
+// Ignored reports whether the path is ignored. Only the path itself is matched, so callers should check the
+// directories it's in too.
+func (m Matcher) Ignored(p string, isDir bool) bool {
+	var ignored bool
+	for _, r := range m.rules {
+		rel, err := filepath.Rel(r.dir, p)
+		if err != nil || rel == "." || !filepath.IsLocal(rel) {
+			continue
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range r.patterns {
+			if pattern.match(rel, isDir, r.anywhere) {
+				ignored = !pattern.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// globExpr converts a glob to a regular expression, where "*" and "?" don't match slashes but "**" does.
+func globExpr(glob string) string {
+	var b strings.Builder
+	for i := 0; i < len(glob); i++ {
+		atElemStart := i == 0 || glob[i-1] == '/'
+		switch c := glob[i]; {
+		case atElemStart && strings.HasPrefix(glob[i:], "**/"):
+			b.WriteString(`(?:.*/)?`)
+			i += 2
+		case atElemStart && glob[i:] == "**":
+			b.WriteString(`.*`)
+			i++
+		case c == '*':
+			b.WriteString(`[^/]*`)
+		case c == '?':
+			b.WriteString(`[^/]`)
+		case c == '[' && strings.IndexByte(glob[i+1:], ']') > 0:
+			class := glob[i+1 : i+1+strings.IndexByte(glob[i+1:], ']')]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + strings.ReplaceAll(class, `\`, `\\`) + "]")
+			i += len(class) + 1
+		case c == '\\' && i+1 < len(glob):
+			b.WriteString(regexp.QuoteMeta(glob[i+1 : i+2]))
+			i++
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
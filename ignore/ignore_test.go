@@ -0,0 +1,94 @@
+package ignore_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/ignore"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	patterns, err := ignore.Parse(strings.NewReader("# comment\n\n_incomplete/\n*.sample.flac  \n!keep.sample.flac\n"))
+	require.NoError(t, err)
+	require.Len(t, patterns, 3)
+	assert.Equal(t, "*.sample.flac", patterns[1].String())
+
+	_, err = ignore.Parse(strings.NewReader("ok\n!\n"))
+	assert.ErrorIs(t, err, ignore.ErrInvalidPattern)
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestIgnored(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "a", "b"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignore.FileName), []byte("/root-only\n*.sample.flac\nsamples/\nsub/**/x\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "a", ignore.FileName), []byte("!keep.sample.flac\n"), 0o644))
+
+	global := []ignore.Pattern{mustParse(t, ".stfolder"), mustParse(t, "_incomplete/"), mustParse(t, "clips/*.mp3")}
+	m, err := ignore.New(filepath.Join(dir, "a"), global)
+	require.NoError(t, err)
+
+	cases := []struct {
+		path    string
+		isDir   bool
+		ignored bool
+	}{
+		{"a/b/.stfolder", true, true},
+		{"a/_incomplete", true, true},
+		{"a/_incomplete", false, false},
+		{"a/b/clips/1.mp3", false, true},
+		{"a/b/clips/1.flac", false, false},
+		{"a/b/01.sample.flac", false, true},
+		{"a/b/keep.sample.flac", false, false},
+		{"a/b/samples", true, true},
+		{"a/root-only", true, false},
+		{"sub/x", false, true},
+		{"a/sub/x", false, false},
+		{"sub/c/d/x", false, true},
+		{"a/b/01.flac", false, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.ignored, m.Ignored(filepath.Join(dir, c.path), c.isDir), c.path)
+	}
+
+	// the global patterns don't apply above the dir
+	m, err = ignore.New(filepath.Join(dir, "a", "b"), global)
+	require.NoError(t, err)
+	assert.False(t, m.Ignored(filepath.Join(dir, "a", "_incomplete"), true))
+	assert.True(t, m.Ignored(filepath.Join(dir, "root-only"), true))
+}
+
+func TestWithFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ignore.FileName), []byte("*.log\n"), 0o644))
+
+	var m ignore.Matcher
+	assert.False(t, m.Ignored(filepath.Join(dir, "rip.log"), false))
+
+	m, err := m.WithFile(dir)
+	require.NoError(t, err)
+	assert.True(t, m.Ignored(filepath.Join(dir, "rip.log"), false))
+
+	m, err = m.WithFile(filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.True(t, m.Ignored(filepath.Join(dir, "rip.log"), false))
+}
+
+func mustParse(t *testing.T, str string) ignore.Pattern {
+	t.Helper()
+
+	p, err := ignore.ParsePattern(str)
+	require.NoError(t, err)
+	return p
+}
@@ -0,0 +1,107 @@
+package imageutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+)
+
+// A small decoder for the uncompressed 24 and 32 bit BMPs that are sometimes found as covers, so that they can be
+// converted without pulling in golang.org/x/image.
+
+func init() {
+	image.RegisterFormat("bmp", "BM????\x00\x00\x00\x00", decodeBMP, decodeBMPConfig)
+}
+
+var errUnsupportedBMP = errors.New("unsupported bmp, only uncompressed 24 and 32 bit are supported")
+
+type bmpHeader struct {
+	offset        uint32
+	width, height int
+	topDown       bool
+	bpp           uint16
+}
+
+func readBMPHeader(r io.Reader) (bmpHeader, error) {
+	var file [14]byte
+	if _, err := io.ReadFull(r, file[:]); err != nil {
+		return bmpHeader{}, fmt.Errorf("read file header: %w", err)
+	}
+	if string(file[:2]) != "BM" {
+		return bmpHeader{}, fmt.Errorf("not a bmp")
+	}
+
+	var info [40]byte // BITMAPINFOHEADER, later versions only add to it
+	if _, err := io.ReadFull(r, info[:]); err != nil {
+		return bmpHeader{}, fmt.Errorf("read info header: %w", err)
+	}
+	infoSize := binary.LittleEndian.Uint32(info[0:])
+	width := int32(binary.LittleEndian.Uint32(info[4:]))
+	height := int32(binary.LittleEndian.Uint32(info[8:]))
+	bpp := binary.LittleEndian.Uint16(info[14:])
+	compression := binary.LittleEndian.Uint32(info[16:])
+
+	const biRGB, biBitfields = 0, 3
+	if infoSize < 40 || width <= 0 || height == 0 {
+		return bmpHeader{}, errUnsupportedBMP
+	}
+	if !(bpp == 24 && compression == biRGB) && !(bpp == 32 && (compression == biRGB || compression == biBitfields)) {
+		return bmpHeader{}, errUnsupportedBMP
+	}
+
+	h := bmpHeader{
+		offset: binary.LittleEndian.Uint32(file[10:]),
+		width:  int(width),
+		height: int(height),
+		bpp:    bpp,
+	}
+	if height < 0 {
+		h.height, h.topDown = int(-height), true
+	}
+	if h.offset < 14+40 {
+		return bmpHeader{}, errUnsupportedBMP
+	}
+	return h, nil
+}
+
+func decodeBMPConfig(r io.Reader) (image.Config, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.RGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+func decodeBMP(r io.Reader) (image.Image, error) {
+	h, err := readBMPHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, int64(h.offset)-14-40); err != nil {
+		return nil, fmt.Errorf("skip to pixels: %w", err)
+	}
+
+	pixSize := int(h.bpp) / 8
+	stride := (h.width*pixSize + 3) &^ 3 // rows are padded to 4 bytes
+	row := make([]byte, stride)
+
+	img := image.NewRGBA(image.Rect(0, 0, h.width, h.height))
+	for i := range h.height {
+		if _, err := io.ReadFull(r, row); err != nil {
+			return nil, fmt.Errorf("read row: %w", err)
+		}
+		y := h.height - 1 - i
+		if h.topDown {
+			y = i
+		}
+		out := img.Pix[y*img.Stride:]
+		for x := range h.width {
+			p := row[x*pixSize:]
+			out[x*4+0], out[x*4+1], out[x*4+2], out[x*4+3] = p[2], p[1], p[0], 255
+		}
+	}
+	return img, nil
+}
@@ -0,0 +1,138 @@
+// imageutil decodes, scales, and encodes cover images without any external dependencies
+package imageutil
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"os"
+
+	_ "image/gif"
+)
+
+// DefaultJPEGQuality is the quality used for re-encoded images.
+const DefaultJPEGQuality = 90
+
+// DecodeConfig returns the dimensions and MIME type of an image.
+func DecodeConfig(data []byte) (image.Config, string, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return image.Config{}, "", fmt.Errorf("decode config: %w", err)
+	}
+	return config, http.DetectContentType(data), nil
+}
+
+// DecodeConfigFile returns the dimensions of the image at path, only reading as much as its header.
+func DecodeConfigFile(path string) (image.Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return image.Config{}, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	config, _, err := image.DecodeConfig(bufio.NewReader(f))
+	if err != nil {
+		return image.Config{}, fmt.Errorf("decode config: %w", err)
+	}
+	return config, nil
+}
+
+// ScaleJPEG decodes the image, scales it down so that neither side is larger than maxDim, and encodes it as a JPEG.
+// If maxDim is 0 the image is only re-encoded.
+func ScaleJPEG(data []byte, maxDim int, quality int) ([]byte, error) {
+	return Reencode(data, maxDim, "image/jpeg", quality)
+}
+
+// Reencode decodes the image, scales it down so that neither side is larger than maxDim, and encodes it with the
+// MIME type, which is either image/jpeg or image/png. If maxDim is 0 the image is only re-encoded.
+func Reencode(data []byte, maxDim int, mime string, quality int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	if maxDim > 0 {
+		img = Scale(img, maxDim)
+	}
+	var buf bytes.Buffer
+	switch mime {
+	case "image/jpeg":
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+			return nil, fmt.Errorf("encode jpeg: %w", err)
+		}
+	case "image/png":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("encode png: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("can't encode %q", mime)
+	}
+	return buf.Bytes(), nil
+}
+
+// Ext returns the usual file extension for an image MIME type, or an empty string if it isn't known.
+func Ext(mime string) string {
+	switch mime {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/bmp":
+		return ".bmp"
+	}
+	return ""
+}
+
+// ScaledSize returns the size that [Scale] scales an image of this size to, keeping its aspect ratio.
+func ScaledSize(w, h int, maxDim int) (int, int) {
+	if maxDim <= 0 || (w <= maxDim && h <= maxDim) {
+		return w, h
+	}
+	if w > h {
+		return maxDim, max(1, h*maxDim/w)
+	}
+	return max(1, w*maxDim/h), maxDim
+}
+
+// Scale scales the image down so that neither side is larger than maxDim, keeping its aspect ratio. Each pixel is the
+// average of the pixels it covers in the original, which is good for the large reductions typical of covers. Images
+// that are already small enough are returned as they are.
+func Scale(img image.Image, maxDim int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= maxDim && sh <= maxDim {
+		return img
+	}
+
+	dw, dh := ScaledSize(sw, sh, maxDim)
+
+	src := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := range dh {
+		y0, y1 := dy*sh/dh, max((dy+1)*sh/dh, dy*sh/dh+1)
+		for dx := range dw {
+			x0, x1 := dx*sw/dw, max((dx+1)*sw/dw, dx*sw/dw+1)
+
+			var r, g, b, a, n int
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride:]
+				for x := x0; x < x1; x++ {
+					p := row[x*4 : x*4+4]
+					r, g, b, a = r+int(p[0]), g+int(p[1]), b+int(p[2]), a+int(p[3])
+					n++
+				}
+			}
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o+0], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = uint8(r/n), uint8(g/n), uint8(b/n), uint8(a/n)
+		}
+	}
+	return dst
+}
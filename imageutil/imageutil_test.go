@@ -0,0 +1,89 @@
+package imageutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScale(t *testing.T) {
+	t.Parallel()
+
+	img := image.NewRGBA(image.Rect(0, 0, 400, 200))
+	for y := range 200 {
+		for x := range 400 {
+			c := color.RGBA{A: 255}
+			if x%2 == 0 {
+				c.R = 200
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	scaled := Scale(img, 100)
+	assert.Equal(t, image.Rect(0, 0, 100, 50), scaled.Bounds())
+	assert.Equal(t, color.RGBA{R: 100, A: 255}, scaled.At(10, 10)) // averaged
+
+	assert.Same(t, img, Scale(img, 400))
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+
+	data, err := ScaleJPEG(buf.Bytes(), 50, DefaultJPEGQuality)
+	require.NoError(t, err)
+
+	config, mime, err := DecodeConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", mime)
+	assert.Equal(t, [2]int{50, 25}, [2]int{config.Width, config.Height})
+}
+
+func TestScaledSize(t *testing.T) {
+	t.Parallel()
+
+	w, h := ScaledSize(497, 500, 200)
+	assert.Equal(t, [2]int{198, 200}, [2]int{w, h})
+	w, h = ScaledSize(400, 200, 100)
+	assert.Equal(t, [2]int{100, 50}, [2]int{w, h})
+	w, h = ScaledSize(400, 200, 0)
+	assert.Equal(t, [2]int{400, 200}, [2]int{w, h})
+	w, h = ScaledSize(100, 50, 200)
+	assert.Equal(t, [2]int{100, 50}, [2]int{w, h})
+}
+
+func TestDecodeBMP(t *testing.T) {
+	t.Parallel()
+
+	// 3x2, 24 bit, bottom up, so the first row in the file is the bottom of the image
+	var bmp bytes.Buffer
+	bmp.WriteString("BM")
+	_ = binary.Write(&bmp, binary.LittleEndian, []uint32{14 + 40 + 2*12, 0, 14 + 40})
+	_ = binary.Write(&bmp, binary.LittleEndian, []uint32{40, 3, 2})
+	_ = binary.Write(&bmp, binary.LittleEndian, []uint16{1, 24})
+	_ = binary.Write(&bmp, binary.LittleEndian, []uint32{0, 0, 0, 0, 0, 0})
+	bmp.Write([]byte{255, 0, 0, 255, 0, 0, 255, 0, 0, 0, 0, 0}) // blue, padded to 12 bytes
+	bmp.Write([]byte{0, 0, 255, 0, 0, 255, 0, 0, 255, 0, 0, 0}) // red
+
+	config, mime, err := DecodeConfig(bmp.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, "image/bmp", mime)
+	assert.Equal(t, [2]int{3, 2}, [2]int{config.Width, config.Height})
+
+	img, format, err := image.Decode(bytes.NewReader(bmp.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, "bmp", format)
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, img.At(0, 0))
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, img.At(2, 1))
+
+	data, err := Reencode(bmp.Bytes(), 0, "image/png", 0)
+	require.NoError(t, err)
+	_, mime, err = DecodeConfig(data)
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", mime)
+}
@@ -1,11 +1,13 @@
 package musicbrainz
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
@@ -44,55 +46,128 @@ func (c *CAAClient) request(ctx context.Context, r *http.Request, dest any) erro
 	return nil
 }
 
+// GetCoverURL returns the URL of the release's front cover, or its release group's if it doesn't have one.
 func (c *CAAClient) GetCoverURL(ctx context.Context, release *Release) (string, error) {
+	covers, err := c.GetCovers(ctx, release)
+	if err != nil {
+		return "", err
+	}
+	if len(covers) == 0 {
+		return "", nil
+	}
+	return covers[0].URL, nil
+}
+
+// GetCovers returns the release's front covers, followed by its release group's as fallbacks.
+func (c *CAAClient) GetCovers(ctx context.Context, release *Release) ([]CAAImage, error) {
 	var candidateURLs []string
 	if release.CoverArtArchive.Front {
 		candidateURLs = append(candidateURLs, joinPath(c.BaseURL, "release", release.ID))
 	}
 	candidateURLs = append(candidateURLs, joinPath(c.BaseURL, "release-group", release.ReleaseGroup.ID))
 
+	var covers []CAAImage
 	for _, candidate := range candidateURLs {
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, candidate, nil)
-		if err != nil {
-			return "", fmt.Errorf("create request: %w", err)
-		}
-
-		var caa caaResponse
-		err = c.request(ctx, req, &caa)
-		if se := StatusError(0); errors.As(err, &se) && se == http.StatusNotFound {
+		caa, err := c.getImages(ctx, candidate)
+		if errors.Is(err, errCAANotFound) {
 			continue
 		}
 		if err != nil {
-			return "", fmt.Errorf("make caa release request: %w", err)
+			return nil, fmt.Errorf("make caa release request: %w", err)
 		}
 
 		for _, img := range caa.Images {
-			if img.Front {
-				return img.Image, nil
+			if img.Front && !slices.ContainsFunc(covers, func(c CAAImage) bool { return c.URL == img.Image }) {
+				covers = append(covers, newCAAImage(img))
 			}
 		}
-		return "", nil
 	}
-	return "", nil
+	return covers, nil
+}
+
+// CAAImage is an image of a release in the Cover Art Archive.
+type CAAImage struct {
+	ID         string // stays the same when other images are added or reordered
+	URL        string
+	Types      []string       // such as "Front", "Back", "Booklet", or "Medium"
+	Thumbnails map[int]string // by their size in pixels, such as 250, 500, and 1200
+	Comment    string
+}
+
+func newCAAImage(img caaImage) CAAImage {
+	thumbnails := map[int]string{}
+	for size, url := range map[int]string{
+		250:  cmp.Or(img.Thumbnails.Num250, img.Thumbnails.Small),
+		500:  cmp.Or(img.Thumbnails.Num500, img.Thumbnails.Large),
+		1200: img.Thumbnails.Num1200,
+	} {
+		if url != "" {
+			thumbnails[size] = url
+		}
+	}
+	return CAAImage{ID: img.ID.String(), URL: img.Image, Types: img.Types, Thumbnails: thumbnails, Comment: img.Comment}
+}
+
+// GetImages returns all of the release's images, in the order the Cover Art Archive lists them. Unlike
+// [CAAClient.GetCovers], images of the release group aren't used.
+func (c *CAAClient) GetImages(ctx context.Context, release *Release) ([]CAAImage, error) {
+	if !release.CoverArtArchive.Artwork {
+		return nil, nil
+	}
+
+	caa, err := c.getImages(ctx, joinPath(c.BaseURL, "release", release.ID))
+	if errors.Is(err, errCAANotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("make caa release request: %w", err)
+	}
+
+	var images []CAAImage
+	for _, img := range caa.Images {
+		images = append(images, newCAAImage(img))
+	}
+	return images, nil
+}
+
+var errCAANotFound = errors.New("not found")
+
+func (c *CAAClient) getImages(ctx context.Context, url string) (*caaResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+
+	var caa caaResponse
+	err = c.request(ctx, req, &caa)
+	if se := StatusError(0); errors.As(err, &se) && se == http.StatusNotFound {
+		return nil, errCAANotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &caa, nil
 }
 
 type caaResponse struct {
-	Release string `json:"release"`
-	Images  []struct {
-		Approved   bool     `json:"approved"`
-		Back       bool     `json:"back"`
-		Comment    string   `json:"comment"`
-		Edit       int      `json:"edit"`
-		Front      bool     `json:"front"`
-		ID         any      `json:"id"`
-		Image      string   `json:"image"`
-		Types      []string `json:"types"`
-		Thumbnails struct {
-			Num250  string `json:"250"`
-			Num500  string `json:"500"`
-			Num1200 string `json:"1200"`
-			Large   string `json:"large"`
-			Small   string `json:"small"`
-		} `json:"thumbnails"`
-	} `json:"images"`
+	Release string     `json:"release"`
+	Images  []caaImage `json:"images"`
+}
+
+type caaImage struct {
+	Approved   bool        `json:"approved"`
+	Back       bool        `json:"back"`
+	Comment    string      `json:"comment"`
+	Edit       int         `json:"edit"`
+	Front      bool        `json:"front"`
+	ID         json.Number `json:"id"`
+	Image      string      `json:"image"`
+	Types      []string    `json:"types"`
+	Thumbnails struct {
+		Num250  string `json:"250"`
+		Num500  string `json:"500"`
+		Num1200 string `json:"1200"`
+		Large   string `json:"large"`
+		Small   string `json:"small"`
+	} `json:"thumbnails"`
 }
@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -23,6 +24,9 @@ type MBClient struct {
 	BaseURL   string
 	RateLimit time.Duration
 
+	// Relationships requests artist, recording and work relationships with releases
+	Relationships bool
+
 	initOnce   sync.Once
 	HTTPClient *http.Client
 }
@@ -30,6 +34,7 @@ type MBClient struct {
 func (c *MBClient) request(ctx context.Context, r *http.Request, dest any) error {
 	c.initOnce.Do(func() {
 		c.HTTPClient = clientutil.Wrap(c.HTTPClient, clientutil.Chain(
+			clientutil.WithDedupe(),
 			clientutil.WithRateLimit(c.RateLimit),
 		))
 	})
@@ -52,7 +57,12 @@ func (c *MBClient) request(ctx context.Context, r *http.Request, dest any) error
 func (c *MBClient) GetRelease(ctx context.Context, mbid string) (*Release, error) {
 	urlV := url.Values{}
 	urlV.Set("fmt", "json")
-	urlV.Set("inc", "recordings+artist-credits+labels+release-groups+genres+aliases")
+	inc := "recordings+artist-credits+labels+release-groups+genres+aliases+isrcs"
+	if c.Relationships {
+		// work-level-rels is needed too so that works include their composers and lyricists
+		inc += "+recording-level-rels+work-rels+work-level-rels+artist-rels"
+	}
+	urlV.Set("inc", inc)
 
 	url, _ := url.Parse(joinPath(c.BaseURL, "release", mbid))
 	url.RawQuery = urlV.Encode()
@@ -67,6 +77,122 @@ func (c *MBClient) GetRelease(ctx context.Context, mbid string) (*Release, error
 	return &sr, nil
 }
 
+// LookupDiscID returns the IDs of releases with a disc matching discID. If toc is provided, and there is no exact
+// match, MusicBrainz will also return releases with a similar TOC.
+func (c *MBClient) LookupDiscID(ctx context.Context, discID string, toc string) ([]string, error) {
+	// https://musicbrainz.org/doc/MusicBrainz_API#discid
+
+	urlV := url.Values{}
+	urlV.Set("fmt", "json")
+	urlV.Set("cdstubs", "no")
+	if toc != "" {
+		urlV.Set("toc", toc)
+	}
+
+	url, _ := url.Parse(joinPath(c.BaseURL, "discid", discID))
+	url.RawQuery = urlV.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+
+	var resp struct {
+		Releases []struct {
+			ID string `json:"id"`
+		} `json:"releases"`
+	}
+	if err := c.request(ctx, req, &resp); err != nil {
+		if se := StatusError(0); errors.As(err, &se) && se == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("request discid: %w", err)
+	}
+
+	ids := make([]string, 0, len(resp.Releases))
+	for _, r := range resp.Releases {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+// GetPseudoReleaseIDs returns the IDs of the pseudo-releases that translate or transliterate the release's tracklist.
+func (c *MBClient) GetPseudoReleaseIDs(ctx context.Context, mbid string) ([]string, error) {
+	urlV := url.Values{}
+	urlV.Set("fmt", "json")
+	urlV.Set("inc", "release-rels")
+
+	url, _ := url.Parse(joinPath(c.BaseURL, "release", mbid))
+	url.RawQuery = urlV.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+
+	var resp struct {
+		Relations []struct {
+			Type      string `json:"type"`
+			Direction string `json:"direction"`
+			Release   struct {
+				ID string `json:"id"`
+			} `json:"release"`
+		} `json:"relations"`
+	}
+	if err := c.request(ctx, req, &resp); err != nil {
+		return nil, fmt.Errorf("request release rels: %w", err)
+	}
+
+	var ids []string
+	for _, r := range resp.Relations {
+		if r.Type == "transl-tracklisting" && r.Direction == "forward" && r.Release.ID != "" {
+			ids = append(ids, r.Release.ID)
+		}
+	}
+	return ids, nil
+}
+
+// GetWork fetches a work with its artist relationships and the works it's made of or part of.
+func (c *MBClient) GetWork(ctx context.Context, mbid string) (*Work, error) {
+	urlV := url.Values{}
+	urlV.Set("fmt", "json")
+	urlV.Set("inc", "work-rels+artist-rels")
+
+	url, _ := url.Parse(joinPath(c.BaseURL, "work", mbid))
+	url.RawQuery = urlV.Encode()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+
+	var w Work
+	if err := c.request(ctx, req, &w); err != nil {
+		return nil, fmt.Errorf("request work: %w", err)
+	}
+
+	return &w, nil
+}
+
+// FetchParentWorks replaces the parent works found in the release's relationships with complete ones, since
+// releases only include the parent's title and not the other parts it's made of.
+func (c *MBClient) FetchParentWorks(ctx context.Context, release *Release) error {
+	works := map[string]*Work{}
+	for i := range release.Media {
+		for j := range release.Media[i].Tracks {
+			work, _, _ := TrackWorks(release.Media[i].Tracks[j])
+			if work == nil {
+				continue
+			}
+			for k, r := range work.Relations {
+				if !isParentRelation(r) {
+					continue
+				}
+				if _, ok := works[r.Work.ID]; !ok {
+					parent, err := c.GetWork(ctx, r.Work.ID)
+					if err != nil {
+						return fmt.Errorf("get work %s: %w", r.Work.ID, err)
+					}
+					works[r.Work.ID] = parent
+				}
+				work.Relations[k].Work = works[r.Work.ID]
+			}
+		}
+	}
+	return nil
+}
+
 type ReleaseQuery struct {
 	MBReleaseID      string
 	MBArtistID       string
@@ -74,7 +200,7 @@ type ReleaseQuery struct {
 
 	Release      string
 	Artist       string
-	Date         time.Time
+	Date         Date
 	Format       string
 	Label        string
 	CatalogueNum string
@@ -107,7 +233,7 @@ func (c *MBClient) SearchRelease(ctx context.Context, q ReleaseQuery) (*Release,
 		params = append(params, field("artist", strings.ToLower(q.Artist)))
 	}
 	if !q.Date.IsZero() {
-		params = append(params, field("date", q.Date.Format(time.DateOnly)))
+		params = append(params, field("date", q.Date.String()))
 	}
 	if q.Format != "" {
 		params = append(params, field("format", strings.ToLower(q.Format)))
@@ -161,6 +287,28 @@ func (c *MBClient) SearchRelease(ctx context.Context, q ReleaseQuery) (*Release,
 	return release, nil
 }
 
+// https://musicbrainz.org/relationships
+
+type Relation struct {
+	Type         string   `json:"type"`
+	TypeID       string   `json:"type-id"`
+	Direction    string   `json:"direction"`
+	TargetType   string   `json:"target-type"`
+	TargetCredit string   `json:"target-credit"`
+	Attributes   []string `json:"attributes"`
+	OrderingKey  int      `json:"ordering-key"`
+	Artist       *Artist  `json:"artist,omitempty"`
+	Work         *Work    `json:"work,omitempty"`
+}
+
+type Work struct {
+	ID             string     `json:"id"`
+	Title          string     `json:"title"`
+	Type           string     `json:"type"`
+	Disambiguation string     `json:"disambiguation"`
+	Relations      []Relation `json:"relations"`
+}
+
 type ArtistCredit struct {
 	Name       string `json:"name"`
 	JoinPhrase string `json:"joinphrase"`
@@ -209,6 +357,8 @@ type Track struct {
 		Length           int            `json:"length"`
 		Title            string         `json:"title"`
 		Artists          []ArtistCredit `json:"artist-credit"`
+		ISRCs            []string       `json:"isrcs"`
+		Relations        []Relation     `json:"relations"`
 	} `json:"recording"`
 	Number   string         `json:"number"`
 	Position int            `json:"position"`
@@ -265,7 +415,7 @@ type Release struct {
 		Count    int  `json:"count"`
 	} `json:"cover-art-archive"`
 	Artists       []ArtistCredit `json:"artist-credit"`
-	Date          AnyTime        `json:"date"`
+	Date          Date           `json:"date"`
 	Quality       string         `json:"quality"`
 	Media         []Media        `json:"media"`
 	Status        string         `json:"status"`
@@ -280,14 +430,15 @@ type Release struct {
 			Disambiguation string   `json:"disambiguation"`
 			Type           any      `json:"type"`
 		} `json:"area"`
-		Date AnyTime `json:"date"`
+		Date Date `json:"date"`
 	} `json:"release-events"`
 	PackagingID string      `json:"packaging-id"`
 	LabelInfo   []LabelInfo `json:"label-info"`
+	Relations   []Relation  `json:"relations"`
 }
 
 type ReleaseGroup struct {
-	FirstReleaseDate AnyTime                     `json:"first-release-date"`
+	FirstReleaseDate Date                        `json:"first-release-date"`
 	Genres           []Genre                     `json:"genres"`
 	PrimaryTypeID    string                      `json:"primary-type-id"`
 	Disambiguation   string                      `json:"disambiguation"`
@@ -346,7 +497,7 @@ func ArtistsString(credits []ArtistCredit) string {
 func ArtistsEnNames(credits []ArtistCredit) []string {
 	var r []string
 	for _, c := range credits {
-		r = append(r, artistEnName(c.Artist))
+		r = append(r, ArtistLocaleName(c.Artist, enLocales))
 	}
 	return r
 }
@@ -354,7 +505,16 @@ func ArtistsEnNames(credits []ArtistCredit) []string {
 func ArtistsEnString(credits []ArtistCredit) string {
 	var sb strings.Builder
 	for _, c := range credits {
-		sb.WriteString(artistEnName(c.Artist))
+		sb.WriteString(ArtistLocaleName(c.Artist, enLocales))
+		sb.WriteString(c.JoinPhrase)
+	}
+	return sb.String()
+}
+
+func ArtistsSortString(credits []ArtistCredit) string {
+	var sb strings.Builder
+	for _, c := range credits {
+		sb.WriteString(c.Artist.SortName)
 		sb.WriteString(c.JoinPhrase)
 	}
 	return sb.String()
@@ -377,21 +537,158 @@ func ArtistsCreditString(credits []ArtistCredit) string {
 	return sb.String()
 }
 
-const enLocale = "en"
+var enLocales = []string{"en"}
 
-func artistEnName(artist Artist) string {
-	for _, a := range artist.Aliases {
-		if a.Locale == enLocale {
-			return a.Name
-		}
+// ArtistLocaleName returns the artist's name in the first of the locales that it has an alias for, or the artist's
+// own name if there are none.
+func ArtistLocaleName(artist Artist, locales []string) string {
+	if a := artistLocaleAlias(artist, locales); a != nil {
+		return a.Name
 	}
 	return artist.Name
 }
 
+// artistLocaleAlias finds the alias for the first of the locales that the artist has one for. Primary aliases are
+// preferred, and a locale like "en" also matches regional ones like "en_GB". Search hints and ended aliases are
+// never used.
+func artistLocaleAlias(artist Artist, locales []string) *Alias {
+	for _, locale := range locales {
+		var match *Alias
+		for i := range artist.Aliases {
+			a := &artist.Aliases[i]
+			if a.Ended || a.Type == "Search hint" || !localeMatches(a.Locale, locale) {
+				continue
+			}
+			if a.Primary {
+				return a
+			}
+			if match == nil {
+				match = a
+			}
+		}
+		if match != nil {
+			return match
+		}
+	}
+	return nil
+}
+
+func localeMatches(aliasLocale, locale string) bool {
+	if aliasLocale == "" || locale == "" {
+		return false
+	}
+	if strings.EqualFold(aliasLocale, locale) {
+		return true
+	}
+	lang, _, _ := strings.Cut(aliasLocale, "_")
+	return strings.EqualFold(lang, locale)
+}
+
+// LocaliseArtists renames the artists credited on the release, its release group, and its tracks to their names in
+// the first of the locales that they have an alias for. Names as credited are left as they are.
+func LocaliseArtists(release *Release, locales []string) {
+	if len(locales) == 0 {
+		return
+	}
+	localise := func(credits []ArtistCredit) {
+		for i := range credits {
+			if a := artistLocaleAlias(credits[i].Artist, locales); a != nil {
+				credits[i].Artist.Name = a.Name
+				credits[i].Artist.SortName = cmp.Or(a.SortName, credits[i].Artist.SortName)
+			}
+		}
+	}
+	localise(release.Artists)
+	localise(release.ReleaseGroup.Artists)
+	for i := range release.Media {
+		for j := range release.Media[i].Tracks {
+			localise(release.Media[i].Tracks[j].Artists)
+			localise(release.Media[i].Tracks[j].Recording.Artists)
+		}
+	}
+}
+
+// ApplyPseudoRelease replaces the titles of the release, its media, and its tracks with those from a pseudo-release
+// of it, such as a transliteration. It reports false and leaves the release unchanged if the tracklists don't line up.
+func ApplyPseudoRelease(release *Release, pseudo *Release) bool {
+	if len(release.Media) != len(pseudo.Media) {
+		return false
+	}
+	for i := range release.Media {
+		if len(release.Media[i].Tracks) != len(pseudo.Media[i].Tracks) {
+			return false
+		}
+	}
+
+	release.Title = cmp.Or(pseudo.Title, release.Title)
+	for i := range release.Media {
+		release.Media[i].Title = cmp.Or(pseudo.Media[i].Title, release.Media[i].Title)
+		for j := range release.Media[i].Tracks {
+			release.Media[i].Tracks[j].Title = cmp.Or(pseudo.Media[i].Tracks[j].Title, release.Media[i].Tracks[j].Title)
+		}
+	}
+	return true
+}
+
 // https://musicbrainz.org/artist/89ad4ac3-39f7-470e-963a-56509c5463
 // https://musicbrainz.org/tag/special%20purpose
 const variousArtistsMBID = "89ad4ac3-39f7-470e-963a-56509c546377"
 
+// TrackWorks returns the work that the track's recording is a performance of, and the work that it's a part of
+// along with its position there. The release must have been fetched with relationships for these to be found.
+func TrackWorks(trk Track) (work *Work, parent *Work, position int) {
+	for _, r := range trk.Recording.Relations {
+		if r.Type == "performance" && r.Work != nil {
+			work = r.Work
+			break
+		}
+	}
+	if work == nil {
+		return nil, nil, 0
+	}
+	for _, r := range work.Relations {
+		if isParentRelation(r) {
+			return work, r.Work, r.OrderingKey
+		}
+	}
+	return work, nil, 0
+}
+
+// TrackComposers returns the composers of the track's work, or of the work that it's a part of.
+func TrackComposers(trk Track) []string {
+	work, parent, _ := TrackWorks(trk)
+	for _, w := range []*Work{work, parent} {
+		if w == nil {
+			continue
+		}
+		var names []string
+		for _, r := range w.Relations {
+			if r.Type == "composer" && r.Direction == "backward" && r.Artist != nil {
+				names = append(names, cmp.Or(r.TargetCredit, r.Artist.Name))
+			}
+		}
+		if len(names) > 0 {
+			return slices.Compact(names)
+		}
+	}
+	return nil
+}
+
+// WorkParts returns the number of works that w is made of. This is only known for works from [MBClient.GetWork].
+func WorkParts(w *Work) int {
+	var n int
+	for _, r := range w.Relations {
+		if r.Type == "parts" && r.Direction == "forward" && r.Work != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func isParentRelation(r Relation) bool {
+	return r.Type == "parts" && r.Direction == "backward" && r.Work != nil
+}
+
 func IsCompilation(rg ReleaseGroup) bool {
 	if hasComp := slices.Contains(rg.SecondaryTypes, Compilation); hasComp {
 		return true
@@ -429,32 +726,51 @@ type GenreInfo struct {
 	Count uint
 }
 
-func AnyGenres(release *Release) (genres []Genre) {
-	defer func() {
-		genres = mergeAndSortGenres(genres)
-	}()
+func AnyGenres(release *Release) []Genre {
+	bySource := GenresBySource(release)
 
 	// try release and artist first
-	genres = append(genres, release.Genres...)
-	genres = append(genres, release.ReleaseGroup.Genres...)
-	for _, t := range FlatTracks(release.Media) {
-		genres = append(genres, t.Recording.Genres...)
+	var genres []Genre
+	for _, src := range GenreSources {
+		if src != GenreSourceLabel {
+			genres = append(genres, bySource[src]...)
+		}
 	}
+	if len(genres) == 0 {
+		// fallback to label
+		genres = bySource[GenreSourceLabel]
+	}
+	return mergeAndSortGenres(genres)
+}
+
+type GenreSource string
+
+const (
+	GenreSourceRelease      GenreSource = "release"
+	GenreSourceReleaseGroup GenreSource = "release-group"
+	GenreSourceRecording    GenreSource = "recording"
+	GenreSourceArtist       GenreSource = "artist"
+	GenreSourceLabel        GenreSource = "label"
+)
+
+var GenreSources = []GenreSource{GenreSourceRelease, GenreSourceReleaseGroup, GenreSourceRecording, GenreSourceArtist, GenreSourceLabel}
 
-	// add some artist genres too
+// GenresBySource returns the genres of the release and of the entities related to it, grouped by where they came from.
+func GenresBySource(release *Release) map[GenreSource][]Genre {
+	genres := map[GenreSource][]Genre{}
+	genres[GenreSourceRelease] = append(genres[GenreSourceRelease], release.Genres...)
+	genres[GenreSourceReleaseGroup] = append(genres[GenreSourceReleaseGroup], release.ReleaseGroup.Genres...)
+	for _, t := range FlatTracks(release.Media) {
+		genres[GenreSourceRecording] = append(genres[GenreSourceRecording], t.Recording.Genres...)
+	}
 	for _, a := range release.Artists {
-		genres = append(genres, a.Artist.Genres...)
+		genres[GenreSourceArtist] = append(genres[GenreSourceArtist], a.Artist.Genres...)
 	}
 	for _, a := range release.ReleaseGroup.Artists {
-		genres = append(genres, a.Artist.Genres...)
-	}
-	if len(genres) > 0 {
-		return genres
+		genres[GenreSourceArtist] = append(genres[GenreSourceArtist], a.Artist.Genres...)
 	}
-
-	// fallback to label
 	for _, l := range release.LabelInfo {
-		genres = append(genres, l.Label.Genres...)
+		genres[GenreSourceLabel] = append(genres[GenreSourceLabel], l.Label.Genres...)
 	}
 	return genres
 }
@@ -466,24 +782,96 @@ func AnyLabelInfo(release *Release) LabelInfo {
 	return LabelInfo{}
 }
 
-type AnyTime struct {
+// DatePrecision is how much of a [Date] is known.
+type DatePrecision uint8
+
+const (
+	PrecisionYear DatePrecision = iota + 1
+	PrecisionMonth
+	PrecisionDay
+)
+
+// Date is a date that may only be known to the year or month, like MusicBrainz's "1997" or "1997-03". The unknown
+// parts of the embedded time are the first month or day.
+type Date struct {
 	time.Time
+	Precision DatePrecision
 }
 
-func (at *AnyTime) UnmarshalJSON(data []byte) error {
-	var str string
-	if err := json.Unmarshal(data, &str); err != nil {
-		return err
+// AnyTime is the old name for [Date].
+//
+// Deprecated: use [Date], which also keeps the precision of the date.
+type AnyTime = Date
+
+// NewDate returns a date with a precision up to the last of year, month, or day that isn't zero.
+func NewDate(year, month, day int) Date {
+	switch {
+	case year == 0:
+		return Date{}
+	case month == 0:
+		return Date{time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC), PrecisionYear}
+	case day == 0:
+		return Date{time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), PrecisionMonth}
+	default:
+		return Date{time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), PrecisionDay}
 	}
+}
+
+var dateLayouts = []struct {
+	layout    string
+	precision DatePrecision
+}{
+	{time.DateOnly, PrecisionDay},
+	{"2006-01", PrecisionMonth},
+	{"2006", PrecisionYear},
+}
+
+// ParseDate parses dates like "1997", "1997-03", or "1997-03-14". Anything else that looks like a date, such as
+// "14/03/1997", is parsed with day precision.
+func ParseDate(str string) (Date, error) {
+	str = strings.TrimSpace(str)
 	if str == "" {
-		return nil
+		return Date{}, nil
 	}
-	var err error
-	at.Time, err = dateparse.ParseAny(str)
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l.layout, str); err == nil {
+			return Date{t, l.precision}, nil
+		}
+	}
+	t, err := dateparse.ParseAny(str)
 	if err != nil {
-		return fmt.Errorf("parse any: %w", err)
+		return Date{}, fmt.Errorf("parse any: %w", err)
 	}
-	return nil
+	return Date{t, PrecisionDay}, nil
+}
+
+// String formats the date to its precision, or returns an empty string for the zero date.
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	switch d.Precision {
+	case PrecisionYear:
+		return d.Format("2006")
+	case PrecisionMonth:
+		return d.Format("2006-01")
+	default:
+		return d.Format(time.DateOnly)
+	}
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	var err error
+	*d, err = ParseDate(str)
+	return err
 }
 
 func mergeAndSortGenres(genres []Genre) []Genre {
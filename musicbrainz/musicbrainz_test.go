@@ -1,6 +1,7 @@
 package musicbrainz
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -38,3 +39,77 @@ func TestMergeAndSortGenres(t *testing.T) {
 		}),
 	)
 }
+
+func TestArtistLocaleName(t *testing.T) {
+	t.Parallel()
+
+	artist := Artist{
+		Name: "跡部進一",
+		Aliases: []Alias{
+			{Name: "Atobe Shinichi", Locale: "en", Type: "Search hint"},
+			{Name: "Shinichi A.", Locale: "en_GB"},
+			{Name: "Shinichi Atobe", Locale: "en", Primary: true},
+			{Name: "Shin Atobe", Locale: "de", Ended: true},
+		},
+	}
+
+	assert.Equal(t, "Shinichi Atobe", ArtistLocaleName(artist, []string{"en"}))
+	assert.Equal(t, "Shinichi A.", ArtistLocaleName(artist, []string{"en_gb", "en"}))
+	assert.Equal(t, "Shinichi Atobe", ArtistLocaleName(artist, []string{"de", "fr", "en"}))
+	assert.Equal(t, "跡部進一", ArtistLocaleName(artist, []string{"ja"}))
+	assert.Equal(t, "跡部進一", ArtistLocaleName(artist, nil))
+}
+
+func TestApplyPseudoRelease(t *testing.T) {
+	t.Parallel()
+
+	release := Release{Title: "森の中", Media: []Media{{Tracks: []Track{{Title: "一"}, {Title: "二"}}}}}
+
+	ok := ApplyPseudoRelease(&release, &Release{Title: "Mori no Naka", Media: []Media{{Tracks: []Track{{Title: "Ichi"}}}}})
+	assert.False(t, ok)
+	assert.Equal(t, "森の中", release.Title)
+
+	ok = ApplyPseudoRelease(&release, &Release{Title: "Mori no Naka", Media: []Media{{Tracks: []Track{{Title: "Ichi"}, {Title: "Ni"}}}}})
+	assert.True(t, ok)
+	assert.Equal(t, "Mori no Naka", release.Title)
+	assert.Equal(t, []string{"Ichi", "Ni"}, []string{release.Media[0].Tracks[0].Title, release.Media[0].Tracks[1].Title})
+}
+
+func TestDate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in        string
+		exp       string
+		precision DatePrecision
+	}{
+		{"", "", 0},
+		{"1997", "1997", PrecisionYear},
+		{"1997-03", "1997-03", PrecisionMonth},
+		{"1997-03-14", "1997-03-14", PrecisionDay},
+		{"2001-01-01T00:00:00Z", "2001-01-01", PrecisionDay},
+	} {
+		d, err := ParseDate(tc.in)
+		require.NoError(t, err)
+		assert.Equal(t, tc.exp, d.String())
+		assert.Equal(t, tc.precision, d.Precision)
+	}
+
+	_, err := ParseDate("not a date")
+	require.Error(t, err)
+
+	assert.Equal(t, "1997", NewDate(1997, 0, 0).String())
+	assert.Equal(t, "1997-03", NewDate(1997, 3, 0).String())
+	assert.Equal(t, 1997, NewDate(1997, 0, 0).Year())
+
+	// round trips keep the precision
+	var release Release
+	require.NoError(t, json.Unmarshal([]byte(`{"date": "1997-03"}`), &release))
+	data, err := json.Marshal(release.Date)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"1997-03"`, string(data))
+
+	// the old name still works
+	var old AnyTime = NewDate(1997, 3, 14)
+	assert.Equal(t, "1997-03-14", old.String())
+}
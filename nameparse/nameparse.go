@@ -0,0 +1,180 @@
+// Package nameparse finds release and track details in directory and file names, for releases without tags. Patterns
+// are names with fields in braces, like "{artist} - {album} ({year})" or "{track} - {title}".
+package nameparse
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Fields are the fields that can be used in patterns. Media is the release's media, like "CD" or "Vinyl", while
+// format is the file format, like "FLAC" or "320", which matches but isn't used.
+var Fields = []string{"artist", "album", "title", "year", "track", "disc", "media", "format", "label", "catno"}
+
+var fieldExprs = map[string]string{
+	"year":  `\d{4}`,
+	"track": `\d{1,3}`,
+	"disc":  `\d{1,2}`,
+}
+
+var ErrInvalidPattern = errors.New("invalid pattern")
+
+// Pattern matches names against a pattern string. A pattern with slashes also matches the names of parent directories.
+type Pattern struct {
+	str    string
+	expr   *regexp.Regexp
+	fields []string
+	depth  int
+}
+
+func ParsePattern(str string) (Pattern, error) {
+	var exprStr strings.Builder
+	var fields []string
+	exprStr.WriteString("^")
+	for rest := str; rest != ""; {
+		lit, after, ok := strings.Cut(rest, "{")
+		writeLiteral(&exprStr, lit)
+		if !ok {
+			break
+		}
+		field, after, ok := strings.Cut(after, "}")
+		if !ok {
+			return Pattern{}, fmt.Errorf("%w: unclosed field in %q", ErrInvalidPattern, str)
+		}
+		if !slices.Contains(Fields, field) {
+			return Pattern{}, fmt.Errorf("%w: unknown field %q, expected one of %s", ErrInvalidPattern, field, strings.Join(Fields, ", "))
+		}
+		if slices.Contains(fields, field) {
+			return Pattern{}, fmt.Errorf("%w: field %q used twice", ErrInvalidPattern, field)
+		}
+		fields = append(fields, field)
+		fmt.Fprintf(&exprStr, "(%s)", cmp.Or(fieldExprs[field], `.+?`))
+		rest = after
+	}
+	exprStr.WriteString("$")
+	if len(fields) == 0 {
+		return Pattern{}, fmt.Errorf("%w: no fields in %q", ErrInvalidPattern, str)
+	}
+
+	expr, err := regexp.Compile(exprStr.String())
+	if err != nil {
+		return Pattern{}, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+	return Pattern{str: str, expr: expr, fields: fields, depth: strings.Count(str, "/") + 1}, nil
+}
+
+func MustParsePattern(str string) Pattern {
+	p, err := ParsePattern(str)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+func (p Pattern) String() string {
+	return p.str
+}
+
+// Match matches the pattern against the end of path, without its extension if it's a file. It returns the value of
+// each field, or nil if it doesn't match.
+func (p Pattern) Match(path string) map[string]string {
+	m := p.expr.FindStringSubmatch(lastElems(path, p.depth))
+	if m == nil {
+		return nil
+	}
+	values := make(map[string]string, len(p.fields))
+	for i, f := range p.fields {
+		if v := strings.TrimSpace(m[i+1]); v != "" {
+			values[f] = v
+		}
+	}
+	return values
+}
+
+// Match is the values found in a name by a pattern.
+type Match struct {
+	Name    string
+	Pattern Pattern
+	Values  map[string]string
+}
+
+// MatchDir matches the first of the patterns that matches the directory's name, or returns nil if none do.
+func MatchDir(patterns []Pattern, dir string) *Match {
+	for _, p := range patterns {
+		if values := p.Match(dir); values != nil {
+			return &Match{Name: lastElems(dir, p.depth), Pattern: p, Values: values}
+		}
+	}
+	return nil
+}
+
+// MatchFiles matches the first of the patterns that matches the names of all files, so that a single name that happens
+// to match a pattern doesn't mix up fields. It returns a match for each file, or nil if no pattern matches them all.
+func MatchFiles(patterns []Pattern, paths []string) []Match {
+	if len(paths) == 0 {
+		return nil
+	}
+	for _, p := range patterns {
+		var matches []Match
+		for _, path := range paths {
+			path = strings.TrimSuffix(path, filepath.Ext(path))
+			values := p.Match(path)
+			if values == nil {
+				break
+			}
+			matches = append(matches, Match{Name: lastElems(path, p.depth), Pattern: p, Values: values})
+		}
+		if len(matches) == len(paths) {
+			return matches
+		}
+	}
+	return nil
+}
+
+// DirPatterns are the built-in patterns for release directories, tried after any configured ones.
+var DirPatterns = []Pattern{
+	MustParsePattern("{artist} - {album} ({year}) [{format}]"),
+	MustParsePattern("{artist} - {album} ({year})"),
+	MustParsePattern("{artist} - {album} [{format}]"),
+	MustParsePattern("{artist} - {year} - {album}"),
+	MustParsePattern("{artist} - ({year}) {album}"),
+	MustParsePattern("{artist} - {album}"),
+	MustParsePattern("{artist}/({year}) {album}"),
+	MustParsePattern("{artist}/{year} - {album}"),
+}
+
+// FilePatterns are the built-in patterns for track files, tried after any configured ones.
+var FilePatterns = []Pattern{
+	MustParsePattern("{disc}-{track} {artist} - {title}"),
+	MustParsePattern("{disc}-{track} {title}"),
+	MustParsePattern("{track} - {artist} - {title}"),
+	MustParsePattern("{track}. {artist} - {title}"),
+	MustParsePattern("{track} - {title}"),
+	MustParsePattern("{track}. {title}"),
+	MustParsePattern("{track} {title}"),
+	MustParsePattern("{artist} - {title}"),
+}
+
+var spaceExpr = regexp.MustCompile(`\s+`)
+
+// writeLiteral writes literal text from a pattern, where any run of spaces matches one or more spaces.
+func writeLiteral(b *strings.Builder, lit string) {
+	parts := spaceExpr.Split(lit, -1)
+	for i, part := range parts {
+		if i > 0 {
+			b.WriteString(`\s+`)
+		}
+		b.WriteString(regexp.QuoteMeta(part))
+	}
+}
+
+// lastElems returns the last n elements of path, joined with slashes.
+func lastElems(path string, n int) string {
+	elems := strings.Split(filepath.ToSlash(filepath.Clean(path)), "/")
+	return strings.Join(elems[max(0, len(elems)-n):], "/")
+}
@@ -0,0 +1,95 @@
+package nameparse_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/nameparse"
+)
+
+func TestParsePattern(t *testing.T) {
+	t.Parallel()
+
+	for _, str := range []string{"", "no fields", "{artist", "{nope}", "{artist} - {artist}"} {
+		_, err := nameparse.ParsePattern(str)
+		assert.ErrorIs(t, err, nameparse.ErrInvalidPattern, str)
+	}
+
+	p, err := nameparse.ParsePattern("[{catno}] {artist} - {album}")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"catno": "PM-005", "artist": "Jeff Mills", "album": "Kat Moda"}, p.Match("/music/[PM-005] Jeff Mills - Kat Moda"))
+	assert.Nil(t, p.Match("/music/Jeff Mills - Kat Moda"))
+}
+
+func TestMatchDir(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		dir      string
+		expected map[string]string
+	}{
+		{"/dl/Jeff Mills - Kat Moda (1997) [FLAC]", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda", "year": "1997", "format": "FLAC"}},
+		{"/dl/Jeff Mills - Kat Moda (1997)", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda", "year": "1997"}},
+		{"/dl/Jeff Mills - 1997 - Kat Moda", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda", "year": "1997"}},
+		{"/dl/Jeff Mills - Kat Moda - Remixes", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda - Remixes"}},
+		{"/dl/Jeff Mills - Kat Moda - Remixes (1997) [FLAC]", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda - Remixes", "year": "1997", "format": "FLAC"}},
+		{"/dl/Jeff Mills - Kat Moda - Remixes [FLAC]", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda - Remixes", "format": "FLAC"}},
+		{"/dl/Jeff Mills/(1997) Kat Moda", map[string]string{"artist": "Jeff Mills", "album": "Kat Moda", "year": "1997"}},
+		{"/dl/kat_moda", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.dir, func(t *testing.T) {
+			t.Parallel()
+
+			m := nameparse.MatchDir(nameparse.DirPatterns, c.dir)
+			if c.expected == nil {
+				assert.Nil(t, m)
+				return
+			}
+			require.NotNil(t, m)
+			assert.Equal(t, c.expected, m.Values)
+		})
+	}
+}
+
+func TestMatchFiles(t *testing.T) {
+	t.Parallel()
+
+	t.Run("same pattern for all", func(t *testing.T) {
+		t.Parallel()
+
+		// the second name alone would match "{track} - {artist} - {title}"
+		ms := nameparse.MatchFiles(nameparse.FilePatterns, []string{"/dl/01 - Alarms.flac", "/dl/02 - The Bells - Live.flac"})
+		require.Len(t, ms, 2)
+		assert.Equal(t, "{track} - {title}", ms[0].Pattern.String())
+		assert.Equal(t, map[string]string{"track": "01", "title": "Alarms"}, ms[0].Values)
+		assert.Equal(t, map[string]string{"track": "02", "title": "The Bells - Live"}, ms[1].Values)
+		assert.Equal(t, "02 - The Bells - Live", ms[1].Name)
+	})
+
+	t.Run("with artist and disc", func(t *testing.T) {
+		t.Parallel()
+
+		ms := nameparse.MatchFiles(nameparse.FilePatterns, []string{"/dl/1-01 Jeff Mills - Alarms.mp3", "/dl/2-01 Jeff Mills - The Bells.mp3"})
+		require.Len(t, ms, 2)
+		assert.Equal(t, map[string]string{"disc": "2", "track": "01", "artist": "Jeff Mills", "title": "The Bells"}, ms[1].Values)
+	})
+
+	t.Run("configured first", func(t *testing.T) {
+		t.Parallel()
+
+		p := nameparse.MustParsePattern("CD{disc}/{track}_{title}")
+		ms := nameparse.MatchFiles([]nameparse.Pattern{p}, []string{"/dl/CD1/01_Alarms.flac"})
+		require.Len(t, ms, 1)
+		assert.Equal(t, map[string]string{"disc": "1", "track": "01", "title": "Alarms"}, ms[0].Values)
+		assert.Equal(t, "CD1/01_Alarms", ms[0].Name)
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		t.Parallel()
+
+		assert.Nil(t, nameparse.MatchFiles(nameparse.FilePatterns, []string{"/dl/01.flac", "/dl/02.flac"}))
+	})
+}
@@ -0,0 +1,107 @@
+package originfile
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+)
+
+// JSON sidecars all match *.json, so each parser checks for the fields that only its format has.
+
+type jsonObject map[string]any
+
+func parseJSON(hints func(jsonObject) *Hints) ParseFunc {
+	return func(data []byte) (*Hints, error) {
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, nil // not json, or not ours
+		}
+		// beet export writes a list of items
+		if items, ok := v.([]any); ok && len(items) > 0 {
+			v = items[0]
+		}
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return nil, nil
+		}
+		return hints(obj), nil
+	}
+}
+
+// https://beets.readthedocs.io/en/stable/reference/cli.html#export
+func beetsHints(o jsonObject) *Hints {
+	if !o.has("mb_albumid") && !o.has("albumartist") {
+		return nil
+	}
+	return &Hints{
+		Artist:       o.str("albumartist"),
+		Title:        o.str("album"),
+		Label:        o.str("label"),
+		CatalogueNum: o.str("catalognum"),
+		Barcode:      o.str("barcode"),
+		Date:         musicbrainz.NewDate(o.int("year"), o.int("month"), o.int("day")),
+		Media:        o.str("media"),
+		MBReleaseID:  o.str("mb_albumid"),
+	}
+}
+
+func qobuzHints(o jsonObject) *Hints {
+	if !o.has("qobuz_id") && !strings.Contains(o.str("url"), "qobuz.com") {
+		return nil
+	}
+	return &Hints{
+		Artist:    o.obj("artist").str("name"),
+		Title:     o.str("title"),
+		Label:     o.obj("label").str("name"),
+		Barcode:   o.str("upc"),
+		Date:      parseDate(o.str("release_date_original")),
+		Permalink: o.str("url"),
+	}
+}
+
+func deezerHints(o jsonObject) *Hints {
+	if !strings.Contains(o.str("link"), "deezer.com") {
+		return nil
+	}
+	return &Hints{
+		Artist:    o.obj("artist").str("name"),
+		Title:     o.str("title"),
+		Label:     o.str("label"),
+		Barcode:   o.str("upc"),
+		Date:      parseDate(o.str("release_date")),
+		Permalink: o.str("link"),
+	}
+}
+
+func (o jsonObject) has(k string) bool {
+	_, ok := o[k]
+	return ok
+}
+
+func (o jsonObject) str(k string) string {
+	switch v := o[k].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return ""
+}
+
+func (o jsonObject) int(k string) int {
+	switch v := o[k].(type) {
+	case float64:
+		return int(v)
+	case string:
+		i, _ := strconv.Atoi(v)
+		return i
+	}
+	return 0
+}
+
+func (o jsonObject) obj(k string) jsonObject {
+	v, _ := o[k].(map[string]any)
+	return v
+}
@@ -1,17 +1,132 @@
+// Package originfile finds and parses sidecar files that come with downloads, such as gazelle-origin files or store
+// metadata, for hints that help find the release.
 package originfile
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
 
 	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/musicbrainz"
 	"gopkg.in/yaml.v3"
 )
 
+// Sidecar is a file next to the tracks of a release, with hints about which release it is.
+type Sidecar struct {
+	Format string // name of the parser, such as "gazelle-origin" or "bandcamp"
+	Path   string
+	Hints
+}
+
+// Hints are the details from a sidecar that can help find the release. Any of them may be empty.
+type Hints struct {
+	Artist       string
+	Title        string
+	Label        string
+	CatalogueNum string
+	Barcode      string
+	Date         musicbrainz.Date
+	Media        string
+	MBReleaseID  string
+	Permalink    string
+}
+
+func (s Sidecar) String() string {
+	return fmt.Sprintf("%s - %s (%s) [%s #%s]", s.Artist, s.Title, s.Date, s.Label, s.CatalogueNum)
+}
+
+// ParseFunc parses the contents of a sidecar file. It returns nil hints if the file isn't in the format it parses.
+type ParseFunc func(data []byte) (*Hints, error)
+
+type parser struct {
+	name    string
+	pattern string
+	parse   ParseFunc
+}
+
+var registry []parser
+var registryMu sync.Mutex
+
+// Register adds a parser for sidecar files with names matching the glob pattern. Files that match several parsers are
+// given to each in the order they were registered, until one recognises it.
+func Register(name, pattern string, parse ParseFunc) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, p := range registry {
+		if p.name == name {
+			panic(fmt.Errorf("sidecar parser %q already registered", name))
+		}
+	}
+	registry = append(registry, parser{name, pattern, parse})
+}
+
+// FindSidecars finds and parses the sidecars in dir, in the order their parsers were registered.
+func FindSidecars(dir string) ([]Sidecar, error) {
+	registryMu.Lock()
+	parsers := registry
+	registryMu.Unlock()
+
+	var sidecars []Sidecar
+	done := map[string]struct{}{}
+	contents := map[string][]byte{} // files can match several parsers, but are only read once
+	for _, p := range parsers {
+		matches, err := fileutil.GlobDir(dir, p.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("glob for %s: %w", p.name, err)
+		}
+		for _, match := range matches {
+			if _, ok := done[match]; ok {
+				continue
+			}
+			data, ok := contents[match]
+			if !ok {
+				data, err = os.ReadFile(match)
+				if err != nil {
+					return nil, fmt.Errorf("read %s: %w", p.name, err)
+				}
+				contents[match] = data
+			}
+			hints, err := p.parse(data)
+			if err != nil {
+				return nil, fmt.Errorf("parse %s: %w", p.name, err)
+			}
+			if hints == nil {
+				continue
+			}
+			done[match] = struct{}{}
+			sidecars = append(sidecars, Sidecar{Format: p.name, Path: match, Hints: *hints})
+		}
+	}
+	return sidecars, nil
+}
+
+func init() {
+	Register("gazelle-origin", dirPat, parseGazelleOrigin)
+	Register("bandcamp", "info.txt", parseText)
+	Register("nfo", "*.nfo", parseText)
+	Register("beets", "*.json", parseJSON(beetsHints))
+	Register("qobuz", "*.json", parseJSON(qobuzHints))
+	Register("deezer", "*.json", parseJSON(deezerHints))
+}
+
 // https://github.com/x1ppy/gazelle-origin
 
 const dirPat = "origin.y*ml"
 
+func parseGazelleOrigin(data []byte) (*Hints, error) {
+	o, err := parseOriginFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return o.Hints(), nil
+}
+
+// Find parses the first gazelle-origin file in dir, if there is one.
 func Find(dir string) (*OriginFile, error) {
 	matches, err := fileutil.GlobDir(dir, dirPat)
 	if err != nil {
@@ -36,8 +151,12 @@ func Parse(path string) (*OriginFile, error) {
 	}
 	defer f.Close()
 
+	return parseOriginFile(f)
+}
+
+func parseOriginFile(r io.Reader) (*OriginFile, error) {
 	var res OriginFile
-	if err := yaml.NewDecoder(f).Decode(&res); err != nil {
+	if err := yaml.NewDecoder(r).Decode(&res); err != nil {
 		return nil, fmt.Errorf("parse origin file: %w", err)
 	}
 	return &res, nil
@@ -65,3 +184,19 @@ type OriginFile struct {
 func (o *OriginFile) String() string {
 	return fmt.Sprintf("%s - %s (%d) [%s #%s]", o.Artist, o.Name, o.EditionYear, o.RecordLabel, o.CatalogueNumber)
 }
+
+// Hints returns the origin file's details as sidecar hints.
+func (o *OriginFile) Hints() *Hints {
+	h := &Hints{
+		Artist:       o.Artist,
+		Title:        o.Name,
+		Label:        o.RecordLabel,
+		CatalogueNum: o.CatalogueNumber,
+		Media:        strings.ReplaceAll(o.Media, "WEB", "Digital Media"),
+		Permalink:    o.Permalink,
+	}
+	if o.EditionYear > 0 {
+		h.Date = musicbrainz.NewDate(o.EditionYear, 0, 0)
+	}
+	return h
+}
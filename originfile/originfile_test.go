@@ -0,0 +1,83 @@
+package originfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/originfile"
+)
+
+func TestFindSidecars(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(name, data string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644))
+	}
+
+	write("origin.yaml", "Artist: Jeff Mills\nName: Kat Moda\nEdition year: 2009\nMedia: WEB\nRecord label: Purpose Maker\nCatalog number: PMD-002\n")
+	write("info.txt", "Kat Moda\nby Jeff Mills\n\nreleased June 2, 1997\n\nlabel: Purpose Maker\nCat #: PM-005\nUPC: 123\n")
+	write("release.nfo", "  Label....: Axis\n  Source...: CD\n  https://musicbrainz.org/release/e47d04a4-7460-427d-a731-cc82386d85f1\n")
+	write("qobuz.json", `{"title": "Kat Moda", "artist": {"name": "Jeff Mills"}, "label": {"name": "Purpose Maker"}, "upc": "0123", "release_date_original": "1997-06-02", "qobuz_id": 1}`)
+	write("deezer.json", `{"title": "Kat Moda", "artist": {"name": "Jeff Mills"}, "label": "Purpose Maker", "upc": "0456", "release_date": "2009-01-01", "link": "https://www.deezer.com/album/1"}`)
+	write("beets.json", `[{"albumartist": "Jeff Mills", "album": "Kat Moda", "year": 1997, "month": 6, "mb_albumid": "e47d04a4-7460-427d-a731-cc82386d85f1"}]`)
+	write("other.json", `{"some": "thing"}`)
+	write("label.json", `{"title": "Kat Moda", "label": {"name": "Purpose Maker"}}`) // not from qobuz without its keys
+	write("notes.nfo", "nothing to see here\n")
+
+	sidecars, err := originfile.FindSidecars(dir)
+	require.NoError(t, err)
+
+	var formats []string
+	for _, s := range sidecars {
+		formats = append(formats, s.Format+" "+filepath.Base(s.Path))
+	}
+	assert.Equal(t, []string{
+		"gazelle-origin origin.yaml",
+		"bandcamp info.txt",
+		"nfo release.nfo",
+		"beets beets.json",
+		"qobuz qobuz.json",
+		"deezer deezer.json",
+	}, formats)
+
+	assert.Equal(t, originfile.Hints{
+		Artist: "Jeff Mills", Title: "Kat Moda", Label: "Purpose Maker", CatalogueNum: "PMD-002",
+		Date: musicbrainz.NewDate(2009, 0, 0), Media: "Digital Media",
+	}, sidecars[0].Hints)
+	assert.Equal(t, originfile.Hints{
+		Artist: "Jeff Mills", Title: "Kat Moda", Label: "Purpose Maker", CatalogueNum: "PM-005", Barcode: "123",
+		Date: musicbrainz.NewDate(1997, 6, 2),
+	}, sidecars[1].Hints)
+	assert.Equal(t, originfile.Hints{
+		Label: "Axis", Media: "CD", MBReleaseID: "e47d04a4-7460-427d-a731-cc82386d85f1",
+	}, sidecars[2].Hints)
+	assert.Equal(t, originfile.Hints{
+		Artist: "Jeff Mills", Title: "Kat Moda", Date: musicbrainz.NewDate(1997, 6, 0),
+		MBReleaseID: "e47d04a4-7460-427d-a731-cc82386d85f1",
+	}, sidecars[3].Hints)
+	assert.Equal(t, "0123", sidecars[4].Barcode)
+	assert.Equal(t, "Purpose Maker", sidecars[4].Label)
+	assert.Empty(t, sidecars[4].Media) // left to the tags
+	assert.Equal(t, "0456", sidecars[5].Barcode)
+	assert.Equal(t, "https://www.deezer.com/album/1", sidecars[5].Permalink)
+}
+
+func TestFind(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	o, err := originfile.Find(dir)
+	require.NoError(t, err)
+	assert.Nil(t, o)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "origin.yml"), []byte("Artist: Jeff Mills\nName: Kat Moda\nEdition year: 2009\n"), 0o644))
+	o, err = originfile.Find(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "Jeff Mills - Kat Moda (2009) [ #]", o.String())
+}
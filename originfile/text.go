@@ -0,0 +1,98 @@
+package originfile
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+)
+
+// Bandcamp style info.txt files and scene .nfo files are both mostly "Key: value" lines, the nfo usually with some
+// dots or art around them. info.txt files may also start like the album page, with the title, then "by Artist", and
+// a "released June 2, 1997" line.
+
+var textLineExpr = regexp.MustCompile(`^[^A-Za-z]*([A-Za-z][A-Za-z0-9 ._#/]*?)[ .]*:\s*(.*?)[\s|]*$`)
+var releasedExpr = regexp.MustCompile(`^\s*released\s+(.+?)\s*$`)
+var mbReleaseURLExpr = regexp.MustCompile(`musicbrainz\.org/release/([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12})`)
+
+var textKeys = map[string]func(h *Hints, v string){
+	"artist":                 func(h *Hints, v string) { h.Artist = v },
+	"album":                  func(h *Hints, v string) { h.Title = v },
+	"title":                  func(h *Hints, v string) { h.Title = v },
+	"label":                  func(h *Hints, v string) { h.Label = v },
+	"record label":           func(h *Hints, v string) { h.Label = v },
+	"catalog number":         func(h *Hints, v string) { h.CatalogueNum = v },
+	"catalogue number":       func(h *Hints, v string) { h.CatalogueNum = v },
+	"catalog #":              func(h *Hints, v string) { h.CatalogueNum = v },
+	"catalog":                func(h *Hints, v string) { h.CatalogueNum = v },
+	"catno":                  func(h *Hints, v string) { h.CatalogueNum = v },
+	"cat no":                 func(h *Hints, v string) { h.CatalogueNum = v },
+	"cat nr":                 func(h *Hints, v string) { h.CatalogueNum = v },
+	"cat #":                  func(h *Hints, v string) { h.CatalogueNum = v },
+	"upc":                    func(h *Hints, v string) { h.Barcode = v },
+	"ean":                    func(h *Hints, v string) { h.Barcode = v },
+	"barcode":                func(h *Hints, v string) { h.Barcode = v },
+	"year":                   func(h *Hints, v string) { h.Date = parseDate(v) },
+	"date":                   func(h *Hints, v string) { h.Date = parseDate(v) },
+	"release date":           func(h *Hints, v string) { h.Date = parseDate(v) },
+	"released":               func(h *Hints, v string) { h.Date = parseDate(v) },
+	"media":                  func(h *Hints, v string) { h.Media = v },
+	"source":                 func(h *Hints, v string) { h.Media = v },
+	"url":                    func(h *Hints, v string) { h.Permalink = v },
+	"musicbrainz album id":   func(h *Hints, v string) { h.MBReleaseID = v },
+	"musicbrainz release id": func(h *Hints, v string) { h.MBReleaseID = v },
+	"mbid":                   func(h *Hints, v string) { h.MBReleaseID = v },
+}
+
+func parseText(data []byte) (*Hints, error) {
+	var h Hints
+	var found bool
+	var prev string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := sc.Text()
+		if artist, ok := strings.CutPrefix(line, "by "); ok && prev != "" && h.Artist == "" {
+			h.Artist, h.Title = strings.TrimSpace(artist), prev
+			found = true
+		}
+		if strings.TrimSpace(line) != "" {
+			prev = strings.TrimSpace(line)
+		}
+		if m := releasedExpr.FindStringSubmatch(line); m != nil {
+			h.Date = parseDate(m[1])
+		}
+		if m := mbReleaseURLExpr.FindStringSubmatch(line); m != nil && h.MBReleaseID == "" {
+			h.MBReleaseID = m[1]
+			found = true
+		}
+		m := textLineExpr.FindStringSubmatch(line)
+		if m == nil || m[2] == "" {
+			continue
+		}
+		if set, ok := textKeys[normTextKey(m[1])]; ok {
+			set(&h, m[2])
+			found = true
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &h, nil
+}
+
+func normTextKey(k string) string {
+	k = strings.ToLower(k)
+	k = strings.NewReplacer(".", " ", "_", " ").Replace(k)
+	return strings.Join(strings.Fields(k), " ")
+}
+
+func parseDate(v string) musicbrainz.Date {
+	d, _ := musicbrainz.ParseDate(v)
+	return d
+}
@@ -3,6 +3,7 @@ package pathformat
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -21,6 +22,9 @@ const delimL, delimR = "{{", "}}"
 type Format struct {
 	tt   texttemplate.Template
 	root string
+
+	// Classical fills in .Work and .Composer
+	Classical bool
 }
 
 func (pf *Format) Parse(str string) error {
@@ -39,7 +43,7 @@ func (pf *Format) Parse(str string) error {
 	if err != nil {
 		return fmt.Errorf("%w: %w", err, ErrInvalidFormat)
 	}
-	if err := validate(Format{*tmpl, ""}); err != nil {
+	if err := validate(Format{tt: *tmpl}); err != nil {
 		return fmt.Errorf("validate: %w", err)
 	}
 	root, _, ok := strings.Cut(str, delimL)
@@ -47,7 +51,7 @@ func (pf *Format) Parse(str string) error {
 		return fmt.Errorf("find root: %w", ErrInvalidFormat)
 	}
 	root = filepath.Clean(root)
-	*pf = Format{*tmpl, root}
+	*pf = Format{tt: *tmpl, root: root, Classical: pf.Classical}
 	return nil
 }
 
@@ -60,6 +64,27 @@ func (pf *Format) Execute(release *musicbrainz.Release, index int, ext string) (
 		return "", fmt.Errorf("not initialised yet")
 	}
 
+	d := NewData(release, index, ext, pf.Classical)
+
+	var buff strings.Builder
+	if err := pf.tt.Execute(&buff, d); err != nil {
+		return "", fmt.Errorf("create path: %w", err)
+	}
+	destPath := buff.String()
+
+	if strings.HasSuffix(destPath, string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %q: output path has trailing slash", ErrBadData, destPath)
+	}
+	if strings.Contains(destPath, strings.Repeat(string(filepath.Separator), 2)) {
+		return "", fmt.Errorf("%w: %q: output path would contain adjacent filepath seperators", ErrBadData, destPath)
+	}
+	destPath = filepath.Clean(destPath)
+	return destPath, nil
+}
+
+// NewData builds the template data for the track at index in the release, with the file extension ext. Work and
+// Composer are only filled in for classical, and only if every track in the release shares them.
+func NewData(release *musicbrainz.Release, index int, ext string, classical bool) Data {
 	flatTracks := musicbrainz.FlatTracks(release.Media)
 
 	var d Data
@@ -80,25 +105,40 @@ func (pf *Format) Execute(release *musicbrainz.Release, index int, ext string) (
 		}
 		d.ReleaseDisambiguation = strings.Join(parts, ", ")
 	}
+	if classical {
+		d.Work, d.Composer = sharedWork(flatTracks)
+	}
 
 	// make sure these are not used
 	d.Track.Number = ""
 	d.Track.Position = -1
 
-	var buff strings.Builder
-	if err := pf.tt.Execute(&buff, d); err != nil {
-		return "", fmt.Errorf("create path: %w", err)
-	}
-	destPath := buff.String()
+	return d
+}
 
-	if strings.HasSuffix(destPath, string(filepath.Separator)) {
-		return "", fmt.Errorf("%w: %q: output path has trailing slash", ErrBadData, destPath)
-	}
-	if strings.Contains(destPath, strings.Repeat(string(filepath.Separator), 2)) {
-		return "", fmt.Errorf("%w: %q: output path would contain adjacent filepath seperators", ErrBadData, destPath)
+// sharedWork returns the title and composers of the work that every track performs, or a movement of. They're empty
+// if the tracks differ, so that every track in the release still shares a directory.
+func sharedWork(tracks []musicbrainz.Track) (work string, composer string) {
+	for i, t := range tracks {
+		var w string
+		if tw, parent, _ := musicbrainz.TrackWorks(t); parent != nil {
+			w = parent.Title
+		} else if tw != nil {
+			w = tw.Title
+		}
+		c := strings.Join(musicbrainz.TrackComposers(t), ", ")
+		if i == 0 {
+			work, composer = w, c
+			continue
+		}
+		if w != work {
+			work = ""
+		}
+		if c != composer {
+			composer = ""
+		}
 	}
-	destPath = filepath.Clean(destPath)
-	return destPath, nil
+	return work, composer
 }
 
 type Data struct {
@@ -110,6 +150,8 @@ type Data struct {
 	Tracks                []musicbrainz.Track
 	TrackNum              int
 	IsCompilation         bool
+	Work                  string
+	Composer              string
 }
 
 func validate(f Format) error {
@@ -164,6 +206,11 @@ func validate(f Format) error {
 	return nil
 }
 
+// Funcs returns the helper functions available to templates.
+func Funcs() texttemplate.FuncMap {
+	return maps.Clone(funcMap)
+}
+
 var funcMap = texttemplate.FuncMap{
 	"join":     func(delim string, items []string) string { return strings.Join(items, delim) },
 	"pad0":     func(amount, n int) string { return fmt.Sprintf("%0*d", amount, n) },
@@ -1,8 +1,9 @@
 package pathformat_test
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
-	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -49,7 +50,7 @@ func TestPathFormat(t *testing.T) {
 	release := &musicbrainz.Release{
 		Title: "Valvable",
 		ReleaseGroup: musicbrainz.ReleaseGroup{
-			FirstReleaseDate: musicbrainz.AnyTime{Time: time.Date(2019, time.January, 0, 0, 0, 0, 0, time.UTC)},
+			FirstReleaseDate: musicbrainz.NewDate(2018, 0, 0),
 		},
 		Artists: []musicbrainz.ArtistCredit{
 			{
@@ -76,3 +77,90 @@ func TestPathFormat(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, `/music/albums/Luke Vibert/(2018) Valvable (Deluxe Edition)/01.01 Sharon's Tone.flac`, path)
 }
+
+func TestPathFormatDatePrecision(t *testing.T) {
+	t.Parallel()
+
+	var pf pathformat.Format
+	require.NoError(t, pf.Parse(`/music/{{ .Release.Title }} ({{ .Release.Date }})/{{ .Track.Title }}{{ .Ext }}`))
+
+	release := &musicbrainz.Release{
+		Title: "Valvable",
+		Date:  musicbrainz.NewDate(2018, 6, 0),
+		Media: []musicbrainz.Media{{Tracks: []musicbrainz.Track{{Title: "Sharon's Tone"}}}},
+	}
+
+	path, err := pf.Execute(release, 0, ".flac")
+	require.NoError(t, err)
+	assert.Equal(t, `/music/Valvable (2018-06)/Sharon's Tone.flac`, path)
+
+	release.Date = musicbrainz.Date{}
+
+	path, err = pf.Execute(release, 0, ".flac")
+	require.NoError(t, err)
+	assert.Equal(t, `/music/Valvable ()/Sharon's Tone.flac`, path)
+}
+
+func TestPathFormatClassical(t *testing.T) {
+	t.Parallel()
+
+	pf := pathformat.Format{Classical: true}
+	require.NoError(t, pf.Parse(`/music/{{ or .Composer (artists .Release.Artists | join "; ") | safepath }}/{{ or .Work .Release.Title | safepath }}/{{ artists .Release.Artists | join "; " | safepath }}/{{ pad0 2 .TrackNum }} {{ .Track.Title | safepath }}{{ .Ext }}`))
+
+	const releaseJSON = `{
+		"title": "Symphonien 5 & 7",
+		"artist-credit": [{"name": "Berliner Philharmoniker", "artist": {"name": "Berliner Philharmoniker"}}],
+		"media": [{"tracks": [{
+			"title": "Symphony No. 5 in C minor, op. 67: I. Allegro con brio",
+			"recording": {"relations": [{"type": "performance", "direction": "forward", "target-type": "work", "work": {
+				"title": "Symphony No. 5 in C minor, op. 67: I. Allegro con brio",
+				"relations": [
+					{"type": "parts", "direction": "backward", "target-type": "work", "ordering-key": 1, "work": {"title": "Symphony No. 5 in C minor, op. 67"}},
+					{"type": "composer", "direction": "backward", "target-type": "artist", "artist": {"name": "Ludwig van Beethoven"}}
+				]
+			}}]}
+		}]}]
+	}`
+
+	var release musicbrainz.Release
+	require.NoError(t, json.Unmarshal([]byte(releaseJSON), &release))
+
+	path, err := pf.Execute(&release, 0, ".flac")
+	require.NoError(t, err)
+	assert.Equal(t, `/music/Ludwig van Beethoven/Symphony No. 5 in C minor, op. 67/Berliner Philharmoniker/01 Symphony No. 5 in C minor, op. 67 I. Allegro con brio.flac`, path)
+
+	// only with the classical option
+	plain := pf
+	plain.Classical = false
+	path, err = plain.Execute(&release, 0, ".flac")
+	require.NoError(t, err)
+	assert.Equal(t, `/music/Berliner Philharmoniker/Symphonien 5 & 7/Berliner Philharmoniker/01 Symphony No. 5 in C minor, op. 67 I. Allegro con brio.flac`, path)
+
+	// a second work by the same composer keeps the composer, but not the work, so the release stays in one directory
+	var seventh musicbrainz.Track
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"title": "Symphony No. 7 in A major, op. 92: I. Poco sostenuto – Vivace",
+		"recording": {"relations": [{"type": "performance", "direction": "forward", "target-type": "work", "work": {
+			"title": "Symphony No. 7 in A major, op. 92: I. Poco sostenuto – Vivace",
+			"relations": [
+				{"type": "parts", "direction": "backward", "target-type": "work", "ordering-key": 1, "work": {"title": "Symphony No. 7 in A major, op. 92"}},
+				{"type": "composer", "direction": "backward", "target-type": "artist", "artist": {"name": "Ludwig van Beethoven"}}
+			]
+		}}]}
+	}`), &seventh))
+	release.Media[0].Tracks = append(release.Media[0].Tracks, seventh)
+
+	for i := range 2 {
+		path, err = pf.Execute(&release, i, ".flac")
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(path, `/music/Ludwig van Beethoven/Symphonien 5 & 7/Berliner Philharmoniker/`), path)
+	}
+	release.Media[0].Tracks = release.Media[0].Tracks[:1]
+
+	// without any works
+	release.Media[0].Tracks[0].Recording.Relations = nil
+
+	path, err = pf.Execute(&release, 0, ".flac")
+	require.NoError(t, err)
+	assert.Equal(t, `/music/Berliner Philharmoniker/Symphonien 5 & 7/Berliner Philharmoniker/01 Symphony No. 5 in C minor, op. 67 I. Allegro con brio.flac`, path)
+}
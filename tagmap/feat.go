@@ -0,0 +1,97 @@
+package tagmap
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// FeatStyle decides where featured artists are written.
+type FeatStyle uint8
+
+const (
+	// FeatAsCredited writes artists and titles as MusicBrainz has them
+	FeatAsCredited FeatStyle = iota
+
+	// FeatInArtist writes featured artists in the artist, like "Artist feat. Other" and "Title"
+	FeatInArtist
+
+	// FeatInTitle writes featured artists in the title, like "Artist" and "Title (feat. Other)"
+	FeatInTitle
+
+	// FeatInBoth writes featured artists in both the artist and the title
+	FeatInBoth
+)
+
+var featStyleNames = []string{
+	FeatAsCredited: "credit",
+	FeatInArtist:   "artist",
+	FeatInTitle:    "title",
+	FeatInBoth:     "both",
+}
+
+func ParseFeatStyle(s string) (FeatStyle, error) {
+	i := slices.Index(featStyleNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown feat style %q. expected one of %s", s, strings.Join(featStyleNames, ", "))
+	}
+	return FeatStyle(i), nil
+}
+
+func (f FeatStyle) String() string {
+	if int(f) < len(featStyleNames) {
+		return featStyleNames[f]
+	}
+	return ""
+}
+
+// matches featured artists like "Title (feat. Other)", "Title [ft Other]", or "Artist featuring Other". without
+// brackets, "feat" and "ft" need a dot so that words like "Ft" aren't mistaken for one
+var featExpr = regexp.MustCompile(`(?i)\s*(?:[(\[]\s*(?:feat\.?|ft\.?|featuring)\s+([^)\]]+?)\s*[)\]]|\s(?:feat\.|ft\.|featuring)\s+(.+)$)`)
+
+// splitFeat splits a featured artists part from an artist or title, returning the rest and the featured artists.
+func splitFeat(s string) (string, string) {
+	m := featExpr.FindStringSubmatchIndex(s)
+	if m == nil {
+		return s, ""
+	}
+	var feat string
+	switch {
+	case m[2] >= 0:
+		feat = s[m[2]:m[3]]
+	case m[4] >= 0:
+		feat = s[m[4]:m[5]]
+	}
+	rest := strings.TrimSpace(s[:m[0]] + s[m[1]:])
+	return rest, strings.TrimSpace(feat)
+}
+
+// featTrack moves featured artists between the track's artist and title according to the style. Featured artists
+// in the artist are used over ones in the title if both have them.
+func featTrack(style FeatStyle, artist, title string) (string, string) {
+	if style == FeatAsCredited {
+		return artist, title
+	}
+	artistRest, artistFeat := splitFeat(artist)
+	titleRest, titleFeat := splitFeat(title)
+	feat := cmp.Or(artistFeat, titleFeat)
+	if feat == "" {
+		return artist, title
+	}
+	switch style {
+	case FeatInArtist:
+		return artistRest + " feat. " + feat, titleRest
+	case FeatInTitle:
+		return artistRest, titleRest + " (feat. " + feat + ")"
+	default:
+		return artistRest + " feat. " + feat, titleRest + " (feat. " + feat + ")"
+	}
+}
+
+// featMatchString formats a track for comparison so that it's the same wherever the featured artists are.
+func featMatchString(artist, title string) string {
+	artist, title = featTrack(FeatInTitle, artist, title)
+	return strings.Join(trim(artist, title), " – ")
+}
@@ -0,0 +1,90 @@
+package tagmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/tags"
+)
+
+func TestSplitFeat(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		in, rest, feat string
+	}{
+		{"Title", "Title", ""},
+		{"Title (feat. Other)", "Title", "Other"},
+		{"Title [ft Other & Another]", "Title", "Other & Another"},
+		{"Title (Featuring Other) (Remix)", "Title (Remix)", "Other"},
+		{"Artist feat. Other", "Artist", "Other"},
+		{"Artist ft. Other", "Artist", "Other"},
+		{"Artist featuring Other", "Artist", "Other"},
+		{"Daft Punk", "Daft Punk", ""},
+		{"Left Feat", "Left Feat", ""},
+	} {
+		rest, feat := splitFeat(tc.in)
+		assert.Equal(t, tc.rest, rest, tc.in)
+		assert.Equal(t, tc.feat, feat, tc.in)
+	}
+}
+
+func TestFeatTrack(t *testing.T) {
+	t.Parallel()
+
+	artist, title := featTrack(FeatAsCredited, "Artist feat. Other", "Title")
+	assert.Equal(t, [2]string{"Artist feat. Other", "Title"}, [2]string{artist, title})
+
+	artist, title = featTrack(FeatInTitle, "Artist feat. Other", "Title")
+	assert.Equal(t, [2]string{"Artist", "Title (feat. Other)"}, [2]string{artist, title})
+
+	artist, title = featTrack(FeatInArtist, "Artist", "Title (ft. Other)")
+	assert.Equal(t, [2]string{"Artist feat. Other", "Title"}, [2]string{artist, title})
+
+	artist, title = featTrack(FeatInBoth, "Artist", "Title (ft. Other)")
+	assert.Equal(t, [2]string{"Artist feat. Other", "Title (feat. Other)"}, [2]string{artist, title})
+
+	artist, title = featTrack(FeatInBoth, "Artist", "Title")
+	assert.Equal(t, [2]string{"Artist", "Title"}, [2]string{artist, title})
+
+	assert.Equal(t, featMatchString("Artist feat. Other", "Title"), featMatchString("Artist", "Title (Feat. Other)"))
+
+	style, err := ParseFeatStyle("Title")
+	require.NoError(t, err)
+	assert.Equal(t, FeatInTitle, style)
+	_, err = ParseFeatStyle("nowhere")
+	require.Error(t, err)
+}
+
+func TestDiffReleaseFeatMatch(t *testing.T) {
+	t.Parallel()
+
+	trk := musicbrainz.Track{Title: "Title", Artists: []musicbrainz.ArtistCredit{
+		{Artist: musicbrainz.Artist{Name: "Artist"}, JoinPhrase: " feat. "},
+		{Artist: musicbrainz.Artist{Name: "Other"}},
+	}}
+	release := &musicbrainz.Release{Media: []musicbrainz.Media{{Tracks: []musicbrainz.Track{trk}}}}
+	local := []tags.Tags{tags.NewTags(tags.Artist, "Artist", tags.Title, "Title (feat. Other)")}
+
+	weights := TagWeights{"release": 0, "artist": 0, "label": 0, "catalogue num": 0, "upc": 0, "media format": 0}
+
+	score, _ := DiffRelease(weights, Options{}, release, release.Media[0].Tracks, local, nil)
+	assert.Less(t, score, 100.0)
+
+	score, diff := DiffRelease(weights, Options{FeatMatch: true}, release, release.Media[0].Tracks, local, nil)
+	assert.Equal(t, 100.0, score)
+	assert.True(t, diff[len(diff)-1].Equal)
+
+	// moving featured artists when writing matches them the same way, so that the next run still matches
+	score, _ = DiffRelease(weights, Options{FeatStyle: FeatInTitle}, release, release.Media[0].Tracks, local, nil)
+	assert.Equal(t, 100.0, score)
+
+	// and written in the title
+	tg := ReleaseTags(release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{FeatStyle: FeatInTitle})
+	assert.Equal(t, "Artist", tg.Get(tags.Artist))
+	assert.Equal(t, "Title (feat. Other)", tg.Get(tags.Title))
+	assert.Equal(t, []string{"Artist", "Other"}, tg.Values(tags.Artists))
+}
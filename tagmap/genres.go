@@ -0,0 +1,186 @@
+package tagmap
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+)
+
+// GenrePolicy filters, renames, and ranks the genres found for a release. Genre names are matched case-insensitively.
+// The zero value keeps every genre, ranked by vote count like [musicbrainz.AnyGenres].
+type GenrePolicy struct {
+	// Allow keeps only these genres, if any are set
+	Allow map[string]struct{}
+
+	// Deny drops these genres, such as "seen live"
+	Deny map[string]struct{}
+
+	// Aliases renames genres before anything else, such as "hip hop" to "Hip-Hop"
+	Aliases map[string]string
+
+	// Tree gives the parents of genres. Votes for a genre also count for each of its parents
+	Tree GenreTree
+
+	// MinCount is the number of votes a genre needs to be kept
+	MinCount int
+
+	// Weights scales the votes from each source, for example to prefer release genres over artist genres. Sources
+	// without a weight have a weight of 1, and sources with a weight of 0 are ignored
+	Weights map[musicbrainz.GenreSource]float64
+}
+
+// Genres returns the genres for the release allowed by the policy, ordered by weighted votes. Genres from labels are
+// only used when there are no others.
+func (p *GenrePolicy) Genres(release *musicbrainz.Release) []musicbrainz.Genre {
+	type tally struct {
+		name  string
+		votes int
+		score float64
+	}
+	tallies := map[string]*tally{}
+	add := func(name string, votes int, score float64) {
+		key := strings.ToLower(name)
+		t, ok := tallies[key]
+		if !ok {
+			t = &tally{name: name}
+			tallies[key] = t
+		}
+		t.votes += votes
+		t.score += score
+	}
+
+	bySource := musicbrainz.GenresBySource(release)
+	count := func(sources ...musicbrainz.GenreSource) {
+		for _, src := range sources {
+			weight, ok := p.Weights[src]
+			if !ok {
+				weight = 1
+			}
+			if weight == 0 {
+				continue
+			}
+			for _, g := range bySource[src] {
+				name := p.alias(g.Name)
+				score := float64(g.Count) * weight
+				add(name, g.Count, score)
+				for _, parent := range p.Tree.Parents(name) {
+					add(p.alias(parent), g.Count, score)
+				}
+			}
+		}
+	}
+
+	count(slices.DeleteFunc(slices.Clone(musicbrainz.GenreSources), func(src musicbrainz.GenreSource) bool {
+		return src == musicbrainz.GenreSourceLabel
+	})...)
+	if len(tallies) == 0 {
+		// fallback to label
+		count(musicbrainz.GenreSourceLabel)
+	}
+
+	var ranked []*tally
+	for key, t := range tallies {
+		if t.votes < p.MinCount {
+			continue
+		}
+		if _, ok := p.Allow[key]; len(p.Allow) > 0 && !ok {
+			continue
+		}
+		if _, ok := p.Deny[key]; ok {
+			continue
+		}
+		ranked = append(ranked, t)
+	}
+	slices.SortFunc(ranked, func(a, b *tally) int {
+		return cmp.Or(
+			cmp.Compare(b.score, a.score),
+			cmp.Compare(a.name, b.name),
+		)
+	})
+
+	genres := make([]musicbrainz.Genre, 0, len(ranked))
+	for _, t := range ranked {
+		genres = append(genres, musicbrainz.Genre{Name: t.name, Count: t.votes})
+	}
+	return genres
+}
+
+func (p *GenrePolicy) alias(name string) string {
+	if to, ok := p.Aliases[strings.ToLower(name)]; ok {
+		return to
+	}
+	return name
+}
+
+// GenreTree maps lowercase genre names to their parent genre.
+type GenreTree map[string]string
+
+// Parents returns the parent of the genre, then its parent, and so on.
+func (gt GenreTree) Parents(name string) []string {
+	var parents []string
+	for {
+		parent, ok := gt[strings.ToLower(name)]
+		if !ok || slices.Contains(parents, parent) {
+			return parents
+		}
+		parents = append(parents, parent)
+		name = parent
+	}
+}
+
+func ParseGenreTreeFile(path string) (GenreTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	defer f.Close()
+
+	return ParseGenreTree(f)
+}
+
+// ParseGenreTree reads a tree of genres, where each line is a genre that's a child of the previous line with less
+// indentation. YAML lists like beets' genres-tree.yaml are also accepted, since list markers and colons are ignored.
+//
+//	electronic
+//	  techno
+//	    detroit techno
+//	hip hop
+func ParseGenreTree(r io.Reader) (GenreTree, error) {
+	type level struct {
+		indent int
+		name   string
+	}
+	var stack []level
+
+	tree := GenreTree{}
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if text := strings.TrimSpace(line); text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t-"))
+		name := strings.TrimSpace(strings.TrimSuffix(strings.TrimLeft(line, " \t-"), ":"))
+		if name == "" {
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			tree[strings.ToLower(name)] = stack[len(stack)-1].name
+		}
+		stack = append(stack, level{indent, name})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("scan: %w", err)
+	}
+	return tree, nil
+}
@@ -0,0 +1,109 @@
+package tagmap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+)
+
+func TestGenrePolicy(t *testing.T) {
+	t.Parallel()
+
+	release := &musicbrainz.Release{
+		Genres: []musicbrainz.Genre{
+			{Name: "detroit techno", Count: 2},
+			{Name: "hip hop", Count: 1},
+		},
+		ReleaseGroup: musicbrainz.ReleaseGroup{
+			Genres: []musicbrainz.Genre{
+				{Name: "Techno", Count: 1},
+				{Name: "seen live", Count: 1},
+			},
+		},
+		Artists: []musicbrainz.ArtistCredit{{Artist: musicbrainz.Artist{Genres: []musicbrainz.Genre{
+			{Name: "house", Count: 4},
+		}}}},
+		LabelInfo: []musicbrainz.LabelInfo{{Label: musicbrainz.Label{Genres: []musicbrainz.Genre{
+			{Name: "minimal", Count: 9},
+		}}}},
+	}
+
+	names := func(genres []musicbrainz.Genre) []string {
+		return mapFunc(genres, func(_ int, g musicbrainz.Genre) string { return g.Name })
+	}
+
+	// the zero value keeps everything except labels, like AnyGenres
+	var p GenrePolicy
+	assert.Equal(t, []string{"house", "detroit techno", "Techno", "hip hop", "seen live"}, names(p.Genres(release)))
+
+	// deny and alias
+	p = GenrePolicy{
+		Deny:    map[string]struct{}{"seen live": {}},
+		Aliases: map[string]string{"hip hop": "Hip-Hop"},
+	}
+	assert.Equal(t, []string{"house", "detroit techno", "Hip-Hop", "Techno"}, names(p.Genres(release)))
+
+	// artist genres count less
+	p.Weights = map[musicbrainz.GenreSource]float64{musicbrainz.GenreSourceArtist: 0.2}
+	assert.Equal(t, []string{"detroit techno", "Hip-Hop", "Techno", "house"}, names(p.Genres(release)))
+
+	// roll up to parents, and only allow the top level
+	p.Tree = GenreTree{"detroit techno": "techno", "techno": "electronic", "house": "electronic"}
+	p.Allow = map[string]struct{}{"electronic": {}, "hip-hop": {}}
+	got := p.Genres(release)
+	assert.Equal(t, []string{"electronic", "Hip-Hop"}, names(got))
+	assert.Equal(t, 7, got[0].Count) // 2 detroit techno + 1 techno + 4 house
+
+	// min count
+	p.MinCount = 2
+	assert.Equal(t, []string{"electronic"}, names(p.Genres(release)))
+
+	// falls back to labels
+	p = GenrePolicy{}
+	assert.Equal(t, []string{"minimal"}, names(p.Genres(&musicbrainz.Release{LabelInfo: release.LabelInfo})))
+}
+
+func TestParseGenreTree(t *testing.T) {
+	t.Parallel()
+
+	tree, err := ParseGenreTree(strings.NewReader(`
+electronic
+  techno
+    detroit techno
+
+    minimal techno
+  house
+hip hop
+  # comment
+  trap
+`))
+	require.NoError(t, err)
+	assert.Equal(t, GenreTree{
+		"techno":         "electronic",
+		"detroit techno": "techno",
+		"minimal techno": "techno",
+		"house":          "electronic",
+		"trap":           "hip hop",
+	}, tree)
+	assert.Equal(t, []string{"techno", "electronic"}, tree.Parents("Detroit Techno"))
+	assert.Empty(t, tree.Parents("electronic"))
+
+	// like beets' genres-tree.yaml
+	tree, err = ParseGenreTree(strings.NewReader(`
+- electronic:
+    - techno:
+        - detroit techno
+    - house
+- hip hop
+`))
+	require.NoError(t, err)
+	assert.Equal(t, GenreTree{
+		"techno":         "electronic",
+		"detroit techno": "techno",
+		"house":          "electronic",
+	}, tree)
+}
@@ -0,0 +1,89 @@
+package tagmap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.senan.xyz/wrtag/tags"
+)
+
+// MergeStrategy decides how a tag's existing local value and the value from MusicBrainz are combined.
+type MergeStrategy uint8
+
+const (
+	// PreferRemote always uses the value from MusicBrainz, even if it's empty
+	PreferRemote MergeStrategy = iota
+
+	// PreferLocal keeps the local value, using the value from MusicBrainz only if there isn't one
+	PreferLocal
+
+	// RemoteIfNonEmpty uses the value from MusicBrainz, keeping the local value if MusicBrainz doesn't have one
+	RemoteIfNonEmpty
+
+	// Union uses the values from MusicBrainz followed by any other local values, for multi-valued tags like GENRES
+	Union
+)
+
+var mergeStrategyNames = []string{
+	PreferRemote:     "prefer-remote",
+	PreferLocal:      "prefer-local",
+	RemoteIfNonEmpty: "remote-if-nonempty",
+	Union:            "union",
+}
+
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	i := slices.Index(mergeStrategyNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown merge strategy %q. expected one of %s", s, strings.Join(mergeStrategyNames, ", "))
+	}
+	return MergeStrategy(i), nil
+}
+
+func (m MergeStrategy) String() string {
+	if int(m) < len(mergeStrategyNames) {
+		return mergeStrategyNames[m]
+	}
+	return ""
+}
+
+// MergeStrategies maps normalised tag keys to their strategy. Tags without one use [PreferRemote].
+type MergeStrategies map[string]MergeStrategy
+
+// Merge updates dest with the local values of each tag that has a strategy.
+func (ms MergeStrategies) Merge(local tags.Tags, dest *tags.Tags) {
+	for k, strategy := range ms {
+		lv, rv := trim(slices.Clone(local.Values(k))...), trim(slices.Clone(dest.Values(k))...)
+
+		var v []string
+		switch strategy {
+		case PreferLocal:
+			v = cmpOrSlice(lv, rv)
+		case RemoteIfNonEmpty:
+			v = cmpOrSlice(rv, lv)
+		case Union:
+			v = slices.Clone(rv)
+			for _, l := range lv {
+				if !slices.Contains(v, l) {
+					v = append(v, l)
+				}
+			}
+		default:
+			continue
+		}
+
+		if len(v) == 0 && !dest.Has(k) {
+			continue
+		}
+		dest.Set(k, v...)
+	}
+}
+
+func cmpOrSlice[T any](vs ...[]T) []T {
+	for _, v := range vs {
+		if len(v) > 0 {
+			return v
+		}
+	}
+	return nil
+}
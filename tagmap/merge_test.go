@@ -0,0 +1,51 @@
+package tagmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/tags"
+)
+
+func TestMergeStrategies(t *testing.T) {
+	t.Parallel()
+
+	local := tags.NewTags(
+		tags.Label, "Purpose Maker",
+		tags.CatalogueNum, "PM-002",
+		tags.Album, "kat moda",
+	)
+	local.Set(tags.Genres, "techno", "minimal")
+
+	dest := tags.NewTags(
+		tags.Label, "",
+		tags.CatalogueNum, "PMD002",
+		tags.Album, "Kat Moda",
+	)
+	dest.Set(tags.Genres, "techno", "electronic")
+
+	ms := MergeStrategies{
+		tags.Label:        RemoteIfNonEmpty,
+		tags.CatalogueNum: PreferLocal,
+		tags.Genres:       Union,
+		tags.MediaFormat:  PreferLocal,
+	}
+	ms.Merge(local, &dest)
+
+	assert.Equal(t, "Purpose Maker", dest.Get(tags.Label))
+	assert.Equal(t, "PM-002", dest.Get(tags.CatalogueNum))
+	assert.Equal(t, "Kat Moda", dest.Get(tags.Album))
+	assert.Equal(t, []string{"techno", "electronic", "minimal"}, dest.Values(tags.Genres))
+	assert.False(t, dest.Has(tags.MediaFormat))
+
+	// local tags are left alone
+	assert.Equal(t, []string{"techno", "minimal"}, local.Values(tags.Genres))
+
+	strategy, err := ParseMergeStrategy("remote-if-nonempty")
+	require.NoError(t, err)
+	assert.Equal(t, RemoteIfNonEmpty, strategy)
+	_, err = ParseMergeStrategy("prefer-neither")
+	require.Error(t, err)
+}
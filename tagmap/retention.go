@@ -0,0 +1,103 @@
+package tagmap
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	dmp "github.com/sergi/go-diff/diffmatchpatch"
+
+	"go.senan.xyz/wrtag/tags"
+)
+
+// RetentionMode decides which of a file's existing tags are kept when wrtag doesn't write them itself.
+type RetentionMode uint8
+
+const (
+	// RetainAll keeps every existing tag
+	RetainAll RetentionMode = iota
+
+	// RetainKnown keeps the existing tags that wrtag knows about, see [tags.IsKnown], and the allowed ones
+	RetainKnown
+
+	// RetainAllowed keeps only the allowed existing tags
+	RetainAllowed
+)
+
+var retentionModeNames = []string{
+	RetainAll:     "all",
+	RetainKnown:   "known",
+	RetainAllowed: "allowed",
+}
+
+func ParseRetentionMode(s string) (RetentionMode, error) {
+	i := slices.Index(retentionModeNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown retention mode %q. expected one of %s", s, strings.Join(retentionModeNames, ", "))
+	}
+	return RetentionMode(i), nil
+}
+
+func (m RetentionMode) String() string {
+	if int(m) < len(retentionModeNames) {
+		return retentionModeNames[m]
+	}
+	return ""
+}
+
+// RetentionPolicy decides which of a file's existing tags survive a retag. The zero value keeps all of them.
+type RetentionPolicy struct {
+	Mode RetentionMode
+
+	// Allow lists extra tags to keep, with normalised keys
+	Allow map[string]struct{}
+}
+
+// Retain reports whether an existing tag is kept when wrtag doesn't write it.
+func (p *RetentionPolicy) Retain(key string) bool {
+	if _, ok := p.Allow[tags.NormKey(key)]; ok {
+		return true
+	}
+	switch p.Mode {
+	case RetainKnown:
+		return tags.IsKnown(key)
+	case RetainAllowed:
+		return false
+	default:
+		return true
+	}
+}
+
+// Apply returns dest with the existing tags that the policy keeps and dest doesn't already have a key for.
+func (p *RetentionPolicy) Apply(existing, dest tags.Tags) tags.Tags {
+	var t tags.Tags
+	for k, vs := range existing.Iter() {
+		if p.Retain(k) {
+			t.Set(k, vs...)
+		}
+	}
+	for k, vs := range dest.Iter() {
+		t.Set(k, vs...)
+	}
+	return t
+}
+
+// Removed returns the existing tags that the policy will remove, that dest doesn't have a key for.
+func (p *RetentionPolicy) Removed(existing, dest tags.Tags) []string {
+	var removed []string
+	for k := range existing.Iter() {
+		if dest.Has(k) || p.Retain(k) {
+			continue
+		}
+		removed = append(removed, k)
+	}
+	return removed
+}
+
+// DiffRemoved returns a diff row listing the removed tags.
+func DiffRemoved(removed []string) Diff {
+	return Diff{
+		Field:  "removed tags",
+		Before: []dmp.Diff{{Type: dmp.DiffDelete, Text: strings.Join(removed, ", ")}},
+	}
+}
@@ -0,0 +1,50 @@
+package tagmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/tags"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	t.Parallel()
+
+	existing := tags.NewTags(
+		tags.Album, "kat moda",
+		tags.ReplayGainTrackGain, "-6 dB",
+		"COMMENT", "ripped by x",
+		"ENCODER", "lame",
+	)
+	dest := tags.NewTags(tags.Album, "Kat Moda", tags.Barcode, "")
+
+	var p RetentionPolicy
+	assert.Empty(t, p.Removed(existing, dest))
+	assert.Equal(t, "-6 dB", p.Apply(existing, dest).Get(tags.ReplayGainTrackGain))
+
+	p = RetentionPolicy{Mode: RetainKnown, Allow: map[string]struct{}{"COMMENT": {}}}
+	assert.Equal(t, []string{"ENCODER"}, p.Removed(existing, dest))
+	got := p.Apply(existing, dest)
+	assert.Equal(t, "Kat Moda", got.Get(tags.Album))
+	assert.Equal(t, "-6 dB", got.Get(tags.ReplayGainTrackGain))
+	assert.Equal(t, "ripped by x", got.Get("COMMENT"))
+	assert.False(t, got.Has("ENCODER"))
+
+	p = RetentionPolicy{Mode: RetainAllowed}
+	assert.Equal(t, []string{"COMMENT", "ENCODER", tags.ReplayGainTrackGain}, p.Removed(existing, dest))
+	got = p.Apply(existing, dest)
+	assert.True(t, got.Has(tags.Barcode))
+	assert.False(t, got.Has(tags.ReplayGainTrackGain))
+
+	assert.True(t, tags.IsKnown("performer:piano"))
+	assert.True(t, tags.IsKnown("album_artist"))
+	assert.False(t, tags.IsKnown("discogs_release_id"))
+
+	mode, err := ParseRetentionMode("Known")
+	require.NoError(t, err)
+	assert.Equal(t, RetainKnown, mode)
+	_, err = ParseRetentionMode("some")
+	require.Error(t, err)
+}
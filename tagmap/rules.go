@@ -0,0 +1,65 @@
+package tagmap
+
+import (
+	"fmt"
+	"strings"
+	texttemplate "text/template"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/pathformat"
+	"go.senan.xyz/wrtag/tags"
+)
+
+// TagRule sets a tag to the output of a template. The template has the same data and helper functions as path formats,
+// see [pathformat.Data].
+type TagRule struct {
+	Key  string
+	tmpl *texttemplate.Template
+}
+
+// ParseTagRule parses a template for the tag key. The template is also tried against an empty release, so that
+// mistakes like unknown fields are found early instead of during an import.
+func ParseTagRule(key, text string) (TagRule, error) {
+	key = tags.NormKey(strings.TrimSpace(key))
+	if key == "" {
+		return TagRule{}, fmt.Errorf("empty tag key")
+	}
+	tmpl, err := texttemplate.
+		New(key).
+		Funcs(pathformat.Funcs()).
+		Parse(text)
+	if err != nil {
+		return TagRule{}, fmt.Errorf("parse template: %w", err)
+	}
+
+	rule := TagRule{Key: key, tmpl: tmpl}
+
+	var release musicbrainz.Release
+	release.Media = []musicbrainz.Media{{Tracks: []musicbrainz.Track{{}}}}
+	if _, err := rule.Execute(&release, 0, ".flac", false); err != nil {
+		return TagRule{}, fmt.Errorf("validate: %w", err)
+	}
+	return rule, nil
+}
+
+// Execute returns the value for the track at index in the release. With classical, .Work and .Composer are filled in.
+func (r TagRule) Execute(release *musicbrainz.Release, index int, ext string, classical bool) (string, error) {
+	var sb strings.Builder
+	if err := r.tmpl.Execute(&sb, pathformat.NewData(release, index, ext, classical)); err != nil {
+		return "", fmt.Errorf("execute %s: %w", r.Key, err)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+// ApplyTagRules sets the tags from each rule in order on top of t, so that a rule can replace a tag from
+// [ReleaseTags]. Rules that output nothing clear their tag.
+func ApplyTagRules(t *tags.Tags, rules []TagRule, release *musicbrainz.Release, index int, ext string, classical bool) error {
+	for _, r := range rules {
+		v, err := r.Execute(release, index, ext, classical)
+		if err != nil {
+			return err
+		}
+		t.Set(r.Key, trim(v)...)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package tagmap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/tags"
+)
+
+func TestTagRules(t *testing.T) {
+	t.Parallel()
+
+	_, err := ParseTagRule("grouping", "{{ .Release.NotAField }}")
+	require.Error(t, err)
+	_, err = ParseTagRule("grouping", "{{ .Release.Title")
+	require.Error(t, err)
+	_, err = ParseTagRule("", "{{ .Release.Title }}")
+	require.Error(t, err)
+
+	var rules []TagRule
+	for _, r := range [][2]string{
+		{"grouping", "{{ .Release.ReleaseGroup.PrimaryType }}"},
+		{"album", "{{ .Release.Title }}{{ if .Release.Disambiguation }} ({{ .Release.Disambiguation }}){{ end }}"},
+		{"comment", "{{ pad0 2 .TrackNum }}{{ .Ext }}"},
+		{"label", ""},
+	} {
+		rule, err := ParseTagRule(r[0], r[1])
+		require.NoError(t, err)
+		rules = append(rules, rule)
+	}
+
+	var release musicbrainz.Release
+	release.Title = "Kat Moda"
+	release.Disambiguation = "deluxe"
+	release.ReleaseGroup.PrimaryType = musicbrainz.EP
+	release.Media = []musicbrainz.Media{{Tracks: []musicbrainz.Track{{Title: "Alarms"}, {Title: "The Bells"}}}}
+
+	tg := tags.NewTags(tags.Album, "Kat Moda", tags.Label, "Purpose Maker")
+	require.NoError(t, ApplyTagRules(&tg, rules, &release, 1, ".flac", false))
+
+	assert.Equal(t, "EP", tg.Get("GROUPING"))
+	assert.Equal(t, "Kat Moda (deluxe)", tg.Get(tags.Album))
+	assert.Equal(t, "02.flac", tg.Get("COMMENT"))
+	assert.Empty(t, tg.Values(tags.Label))
+}
@@ -3,10 +3,10 @@ package tagmap
 import (
 	"cmp"
 	"fmt"
+	"regexp"
 	"slices"
 	"strconv"
 	"strings"
-	"time"
 	"unicode"
 
 	dmp "github.com/sergi/go-diff/diffmatchpatch"
@@ -35,36 +35,64 @@ func (tw TagWeights) For(field string) float64 {
 	return 1
 }
 
-func DiffRelease[T interface{ Get(string) string }](weights TagWeights, release *musicbrainz.Release, tracks []musicbrainz.Track, tagFiles []T) (float64, []Diff) {
+// DiffRelease scores how well the tag files match the release and its tracks. If adjust isn't nil, it's called with
+// the remote values for each track before they're compared, so that changes made to tags before they're written, like
+// tag rules, don't count against the match.
+func DiffRelease[T interface{ Get(string) string }](weights TagWeights, opts Options, release *musicbrainz.Release, tracks []musicbrainz.Track, tagFiles []T, adjust func(i int, t *tags.Tags)) (float64, []Diff) {
 	if len(tracks) == 0 {
 		return 0, nil
 	}
 
 	labelInfo := musicbrainz.AnyLabelInfo(release)
 
+	remote := make([]tags.Tags, len(tracks))
+	for i, trk := range tracks {
+		t := tags.NewTags(
+			tags.Album, release.Title,
+			tags.AlbumArtist, musicbrainz.ArtistsString(release.Artists),
+			tags.Label, labelInfo.Label.Name,
+			tags.CatalogueNum, labelInfo.CatalogNumber,
+			tags.UPC, release.Barcode,
+			tags.MediaFormat, release.Media[0].Format,
+			tags.Artist, musicbrainz.ArtistsString(trk.Artists),
+			tags.Title, trk.Title,
+		)
+		if adjust != nil {
+			adjust(i, &t)
+		}
+		remote[i] = t
+	}
+
 	var score float64
 	diff := Differ(weights, &score)
 
 	var diffs []Diff
 	{
-		tf := tagFiles[0]
+		tf, rt := tagFiles[0], remote[0]
 		diffs = append(diffs,
-			diff("release", tf.Get(tags.Album), release.Title),
-			diff("artist", tf.Get(tags.AlbumArtist), musicbrainz.ArtistsString(release.Artists)),
-			diff("label", tf.Get(tags.Label), labelInfo.Label.Name),
-			diff("catalogue num", tf.Get(tags.CatalogueNum), labelInfo.CatalogNumber),
-			diff("upc", tf.Get(tags.UPC), release.Barcode),
-			diff("media format", tf.Get(tags.MediaFormat), release.Media[0].Format),
+			diff("release", tf.Get(tags.Album), rt.Get(tags.Album)),
+			diff("artist", tf.Get(tags.AlbumArtist), rt.Get(tags.AlbumArtist)),
+			diff("label", tf.Get(tags.Label), rt.Get(tags.Label)),
+			diff("catalogue num", tf.Get(tags.CatalogueNum), rt.Get(tags.CatalogueNum)),
+			diff("upc", tf.Get(tags.UPC), rt.Get(tags.UPC)),
+			diff("media format", tf.Get(tags.MediaFormat), rt.Get(tags.MediaFormat)),
 		)
 	}
 
 	for i := range max(len(tagFiles), len(tracks)) {
+		trackString := func(artist, title string) string {
+			if opts.FeatMatch || opts.FeatStyle != FeatAsCredited {
+				// featured artists are moved when written, so they'd be in a different place on the next run
+				return featMatchString(artist, title)
+			}
+			return strings.Join(trim(artist, title), " – ")
+		}
 		var a, b string
 		if i < len(tagFiles) {
-			a = strings.Join(trim(tagFiles[i].Get(tags.Artist), tagFiles[i].Get(tags.Title)), " – ")
+			a = trackString(tagFiles[i].Get(tags.Artist), tagFiles[i].Get(tags.Title))
 		}
 		if i < len(tracks) {
-			b = strings.Join(trim(musicbrainz.ArtistsString(tracks[i].Artists), tracks[i].Title), " – ")
+			b = trackString(remote[i].Get(tags.Artist), remote[i].Get(tags.Title))
 		}
 		diffs = append(diffs, diff(fmt.Sprintf("track %d", i+1), a, b))
 	}
@@ -79,6 +107,7 @@ func DiffRelease[T interface{ Get(string) string }](weights TagWeights, release
 func ReleaseTags(
 	release *musicbrainz.Release, labelInfo musicbrainz.LabelInfo, genres []musicbrainz.Genre,
 	i int, trk *musicbrainz.Track,
+	opts Options,
 ) tags.Tags {
 	var genreNames []string
 	for _, g := range genres[:min(6, len(genres))] { // top 6 genre strings
@@ -94,35 +123,255 @@ func ReleaseTags(
 	t.Set(tags.AlbumArtists, trim(musicbrainz.ArtistsNames(release.Artists)...)...)
 	t.Set(tags.AlbumArtistCredit, trim(musicbrainz.ArtistsCreditString(release.Artists))...)
 	t.Set(tags.AlbumArtistsCredit, trim(musicbrainz.ArtistsCreditNames(release.Artists)...)...)
-	t.Set(tags.Date, trim(formatDate(release.Date.Time))...)
-	t.Set(tags.OriginalDate, trim(formatDate(release.ReleaseGroup.FirstReleaseDate.Time))...)
+	t.Set(tags.AlbumArtistSort, trim(musicbrainz.ArtistsSortString(release.Artists))...)
+	t.Set(tags.Date, trim(release.Date.String())...)
+	t.Set(tags.OriginalDate, trim(release.ReleaseGroup.FirstReleaseDate.String())...)
+	t.Set(tags.OriginalYear, trim(formatYear(release.ReleaseGroup.FirstReleaseDate))...)
 	t.Set(tags.MediaFormat, trim(release.Media[0].Format)...)
 	t.Set(tags.Label, trim(labelInfo.Label.Name)...)
 	t.Set(tags.CatalogueNum, trim(labelInfo.CatalogNumber)...)
 	t.Set(tags.UPC, trim(release.Barcode)...)
+	t.Set(tags.Barcode, trim(release.Barcode)...)
+	t.Set(tags.ASIN, trim(release.Asin)...)
 	t.Set(tags.Compilation, trim(formatBool(musicbrainz.IsCompilation(release.ReleaseGroup)))...)
+	t.Set(tags.ReleaseType, trim(releaseTypes(release.ReleaseGroup)...)...)
+	t.Set(tags.ReleaseStatus, trim(strings.ToLower(release.Status))...)
+	t.Set(tags.ReleaseCountry, trim(release.Country)...)
+	t.Set(tags.Script, trim(release.TextRepresentation.Script)...)
+	t.Set(tags.Language, trim(release.TextRepresentation.Language)...)
 
 	t.Set(tags.MBReleaseID, trim(release.ID)...)
 	t.Set(tags.MBReleaseGroupID, trim(release.ReleaseGroup.ID)...)
 	t.Set(tags.MBAlbumArtistID, trim(mapFunc(release.Artists, func(_ int, v musicbrainz.ArtistCredit) string { return v.Artist.ID })...)...)
 	t.Set(tags.MBAlbumComment, trim(disambiguation)...)
 
-	t.Set(tags.Title, trim(trk.Title)...)
-	t.Set(tags.Artist, trim(musicbrainz.ArtistsString(trk.Artists))...)
+	artist, title := featTrack(opts.FeatStyle, musicbrainz.ArtistsString(trk.Artists), trk.Title)
+	t.Set(tags.Title, trim(title)...)
+	t.Set(tags.Artist, trim(artist)...)
 	t.Set(tags.Artists, trim(musicbrainz.ArtistsNames(trk.Artists)...)...)
 	t.Set(tags.ArtistCredit, trim(musicbrainz.ArtistsCreditString(trk.Artists))...)
 	t.Set(tags.ArtistsCredit, trim(musicbrainz.ArtistsCreditNames(trk.Artists)...)...)
+	t.Set(tags.ArtistSort, trim(musicbrainz.ArtistsSortString(trk.Artists))...)
 	t.Set(tags.Genre, trim(cmp.Or(genreNames...))...)
 	t.Set(tags.Genres, trim(genreNames...)...)
 	t.Set(tags.TrackNumber, trim(strconv.Itoa(i+1))...)
 	t.Set(tags.DiscNumber, trim(strconv.Itoa(1))...)
+	t.Set(tags.ISRC, trim(trk.Recording.ISRCs...)...)
 
 	t.Set(tags.MBRecordingID, trim(trk.Recording.ID)...)
+	t.Set(tags.MBReleaseTrackID, trim(trk.ID)...)
 	t.Set(tags.MBArtistID, trim(mapFunc(trk.Artists, func(_ int, v musicbrainz.ArtistCredit) string { return v.Artist.ID })...)...)
 
+	if len(opts.Relationships) > 0 {
+		relationTags(&t, opts.Relationships, release, trk)
+	}
+	if opts.Classical {
+		classicalTags(&t, trk)
+	}
+
 	return t
 }
 
+// Options enable optional groups of tags in [ReleaseTags].
+type Options struct {
+	// Relationships is the set of tags to write from artist relationships
+	Relationships Relationships
+
+	// Classical writes work and movement tags, and composers, from the works that recordings are performances of
+	Classical bool
+
+	// FeatMatch compares tracks without regard to whether featured artists are in the artist or the title
+	FeatMatch bool
+
+	// FeatStyle decides where featured artists are written. Any style other than [FeatAsCredited] implies FeatMatch
+	FeatStyle FeatStyle
+}
+
+// Relationships is the set of tags to write from MusicBrainz artist relationships, such as [tags.Composer] or
+// [tags.Performer]. The release must have been fetched with relationships for these to be found.
+type Relationships map[string]struct{}
+
+// RelationshipTags are the tags that can be used in [Relationships].
+var RelationshipTags = []string{
+	tags.Composer, tags.Lyricist, tags.Writer, tags.Arranger, tags.Conductor,
+	tags.Producer, tags.Engineer, tags.Mixer, tags.Remixer, tags.Performer,
+}
+
+// https://musicbrainz.org/relationships/artist-recording
+// https://musicbrainz.org/relationships/artist-work
+var relationTypeTags = map[string]string{
+	"composer":             tags.Composer,
+	"lyricist":             tags.Lyricist,
+	"writer":               tags.Writer,
+	"arranger":             tags.Arranger,
+	"instrument arranger":  tags.Arranger,
+	"vocal arranger":       tags.Arranger,
+	"orchestrator":         tags.Arranger,
+	"conductor":            tags.Conductor,
+	"producer":             tags.Producer,
+	"engineer":             tags.Engineer,
+	"audio":                tags.Engineer,
+	"sound":                tags.Engineer,
+	"recording":            tags.Engineer,
+	"mix":                  tags.Mixer,
+	"remixer":              tags.Remixer,
+	"performer":            tags.Performer,
+	"instrument":           tags.Performer,
+	"vocal":                tags.Performer,
+	"performing orchestra": tags.Performer,
+}
+
+// relationTags sets tags for the artist relationships of the release, the track's recording, and the works that
+// the recording is a performance of. Performers are written with their role, like "PERFORMER:guitar".
+func relationTags(t *tags.Tags, rels Relationships, release *musicbrainz.Release, trk *musicbrainz.Track) {
+	var artistRels []musicbrainz.Relation
+	artistRels = append(artistRels, release.Relations...)
+	artistRels = append(artistRels, trk.Recording.Relations...)
+	for _, r := range trk.Recording.Relations {
+		if r.Type == "performance" && r.Work != nil {
+			artistRels = append(artistRels, r.Work.Relations...)
+		}
+	}
+
+	values := map[string][]string{}
+	for _, r := range artistRels {
+		if r.TargetType != "artist" || r.Artist == nil || r.Direction != "backward" {
+			continue
+		}
+		tag, ok := relationTypeTags[r.Type]
+		if !ok {
+			continue
+		}
+		if _, ok := rels[tag]; !ok {
+			continue
+		}
+		name := cmp.Or(r.TargetCredit, r.Artist.Name)
+		if tag != tags.Performer {
+			values[tag] = appendUnique(values[tag], name)
+			continue
+		}
+		for _, role := range performerRoles(r) {
+			key := tag
+			if role != "" {
+				key += ":" + role
+			}
+			values[key] = appendUnique(values[key], name)
+		}
+	}
+
+	for tag := range rels {
+		t.Set(tag) // clear any previous values
+	}
+	for key, vs := range values {
+		t.Set(key, vs...)
+	}
+}
+
+// ClearRelationRoles clears the keys in existing with a role, like "PERFORMER:piano", for tags in rels that t doesn't
+// set, so that roles which are no longer credited aren't left behind when t is written.
+func ClearRelationRoles(existing tags.Tags, t *tags.Tags, rels Relationships) {
+	for k := range existing.Iter() {
+		tag, _, ok := strings.Cut(k, ":")
+		if !ok {
+			continue
+		}
+		if _, ok := rels[tag]; !ok || t.Has(k) {
+			continue
+		}
+		t.Set(k)
+	}
+}
+
+// classicalTags sets tags for the work that the track's recording is a performance of. If that work is a movement of a
+// larger work, the larger work is written as the work, and the movement's title is trimmed to just its name.
+func classicalTags(t *tags.Tags, trk *musicbrainz.Track) {
+	work, parent, position := musicbrainz.TrackWorks(*trk)
+
+	var workTitle, movementName, movement, movementTotal string
+	switch {
+	case parent != nil:
+		workTitle = parent.Title
+		movementName, movement = movementParts(work.Title, parent.Title, position)
+		if n := musicbrainz.WorkParts(parent); n > 0 {
+			movementTotal = strconv.Itoa(n)
+		}
+	case work != nil:
+		workTitle = work.Title
+	}
+
+	t.Set(tags.Work, trim(workTitle)...)
+	t.Set(tags.MovementName, trim(movementName)...)
+	t.Set(tags.Movement, trim(movement)...)
+	t.Set(tags.MovementTotal, trim(movementTotal)...)
+	t.Set(tags.Composer, trim(musicbrainz.TrackComposers(*trk)...)...)
+}
+
+// matches movement numbers like "IV. " or "4. "
+var movementNumberExpr = regexp.MustCompile(`^([IVXLC]+|\d+)\.\s+`)
+
+// movementParts trims the parent work's title from a movement's title, like "Symphony No. 5 in C minor, Op. 67: I. Allegro
+// con brio" to "Allegro con brio". If position is unknown, the movement number is taken from the title instead.
+func movementParts(title, parentTitle string, position int) (string, string) {
+	name := strings.TrimLeft(strings.TrimPrefix(title, parentTitle), ":,-–— ")
+	if name == "" {
+		name = title
+	}
+	if m := movementNumberExpr.FindStringSubmatch(name); m != nil {
+		name = name[len(m[0]):]
+		if position == 0 {
+			position = parseMovementNumber(m[1])
+		}
+	}
+	if position == 0 {
+		return name, ""
+	}
+	return name, strconv.Itoa(position)
+}
+
+func parseMovementNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	values := map[rune]int{'I': 1, 'V': 5, 'X': 10, 'L': 50, 'C': 100}
+	var n, prev int
+	for _, r := range slices.Backward([]rune(s)) {
+		v := values[r]
+		if v < prev {
+			n -= v
+		} else {
+			n += v
+		}
+		prev = max(prev, v)
+	}
+	return n
+}
+
+// relationModifiers are relationship attributes that describe the credit rather than the instrument or voice
+var relationModifiers = []string{"additional", "guest", "solo", "minor", "assistant", "associate", "co", "executive"}
+
+func performerRoles(r musicbrainz.Relation) []string {
+	roles := slices.DeleteFunc(slices.Clone(r.Attributes), func(a string) bool {
+		return slices.Contains(relationModifiers, a)
+	})
+	if len(roles) > 0 {
+		return roles
+	}
+	switch r.Type {
+	case "vocal":
+		return []string{"vocals"}
+	case "performing orchestra":
+		return []string{"orchestra"}
+	}
+	return []string{""}
+}
+
+func appendUnique(vs []string, v string) []string {
+	if slices.Contains(vs, v) {
+		return vs
+	}
+	return append(vs, v)
+}
+
 func Differ(weights TagWeights, score *float64) func(field string, a, b string) Diff {
 	dm := dmp.New()
 
@@ -163,11 +412,23 @@ func norm(input string) string {
 	}, input)
 }
 
-func formatDate(d time.Time) string {
+func formatYear(d musicbrainz.Date) string {
 	if d.IsZero() {
 		return ""
 	}
-	return d.Format(time.DateOnly)
+	return strconv.Itoa(d.Year())
+}
+
+// releaseTypes returns the primary and secondary types of the release group, lowercased like Picard
+func releaseTypes(rg musicbrainz.ReleaseGroup) []string {
+	var types []string
+	if rg.PrimaryType != "" {
+		types = append(types, strings.ToLower(string(rg.PrimaryType)))
+	}
+	for _, st := range rg.SecondaryTypes {
+		types = append(types, strings.ToLower(string(st)))
+	}
+	return types
 }
 
 func formatBool(b bool) string {
@@ -1,9 +1,14 @@
 package tagmap
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/tags"
 )
 
 func TestDiffer(t *testing.T) {
@@ -100,3 +105,124 @@ func TestNorm(t *testing.T) {
 	assert.Equal(t, "séan", norm("SÉan"))
 	assert.Equal(t, "hello世界", norm("~~ 【 Hello, 世界。 】~~ 😉"))
 }
+
+func TestRelationTags(t *testing.T) {
+	t.Parallel()
+
+	const releaseJSON = `{
+		"media": [{"format": "CD", "tracks": [{
+			"title": "Orinoco Flow",
+			"recording": {
+				"relations": [
+					{"type": "producer", "direction": "backward", "target-type": "artist", "artist": {"name": "Nicky Ryan"}},
+					{"type": "instrument", "direction": "backward", "target-type": "artist", "attributes": ["additional", "synthesizer"], "artist": {"name": "Enya"}},
+					{"type": "vocal", "direction": "backward", "target-type": "artist", "artist": {"name": "Enya"}},
+					{"type": "performance", "direction": "forward", "target-type": "work", "work": {
+						"title": "Orinoco Flow",
+						"relations": [
+							{"type": "composer", "direction": "backward", "target-type": "artist", "artist": {"name": "Enya"}},
+							{"type": "lyricist", "direction": "backward", "target-type": "artist", "target-credit": "Roma Shane Ryan", "artist": {"name": "Roma Ryan"}}
+						]
+					}}
+				]
+			}
+		}]}],
+		"relations": [
+			{"type": "producer", "direction": "backward", "target-type": "artist", "artist": {"name": "Nicky Ryan"}},
+			{"type": "mix", "direction": "backward", "target-type": "artist", "artist": {"name": "Nicky Ryan"}}
+		]
+	}`
+
+	var release musicbrainz.Release
+	require.NoError(t, json.Unmarshal([]byte(releaseJSON), &release))
+	trk := musicbrainz.FlatTracks(release.Media)[0]
+
+	rels := Relationships{tags.Composer: {}, tags.Lyricist: {}, tags.Producer: {}, tags.Performer: {}, tags.Conductor: {}}
+	got := ReleaseTags(&release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{Relationships: rels})
+
+	assert.Equal(t, []string{"Enya"}, got.Values(tags.Composer))
+	assert.Equal(t, []string{"Roma Shane Ryan"}, got.Values(tags.Lyricist))
+	assert.Equal(t, []string{"Nicky Ryan"}, got.Values(tags.Producer))
+	assert.Equal(t, []string{"Enya"}, got.Values(tags.Performer+":synthesizer"))
+	assert.Equal(t, []string{"Enya"}, got.Values(tags.Performer+":vocals"))
+	assert.Empty(t, got.Values(tags.Conductor))
+	assert.Empty(t, got.Values(tags.Mixer)) // not enabled
+
+	got = ReleaseTags(&release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{})
+	assert.Empty(t, got.Values(tags.Composer))
+
+	// roles from an earlier run are cleared, but not ones for relationships that aren't enabled
+	existing := tags.NewTags(tags.Performer+":guitar", "Enya", tags.Performer+":vocals", "Enya", "LYRICS:eng", "la")
+	got = ReleaseTags(&release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{Relationships: rels})
+	ClearRelationRoles(existing, &got, rels)
+	assert.True(t, got.Has(tags.Performer+":guitar"))
+	assert.Empty(t, got.Values(tags.Performer+":guitar"))
+	assert.Equal(t, []string{"Enya"}, got.Values(tags.Performer+":vocals"))
+	assert.False(t, got.Has("LYRICS:eng"))
+}
+
+func TestClassicalTags(t *testing.T) {
+	t.Parallel()
+
+	const releaseJSON = `{
+		"media": [{"format": "CD", "tracks": [{
+			"title": "Symphony No. 5 in C minor, op. 67: II. Andante con moto",
+			"recording": {
+				"relations": [
+					{"type": "conductor", "direction": "backward", "target-type": "artist", "artist": {"name": "Herbert von Karajan"}},
+					{"type": "performance", "direction": "forward", "target-type": "work", "work": {
+						"title": "Symphony No. 5 in C minor, op. 67: II. Andante con moto",
+						"relations": [
+							{"type": "composer", "direction": "backward", "target-type": "artist", "artist": {"name": "Ludwig van Beethoven"}},
+							{"type": "parts", "direction": "backward", "target-type": "work", "ordering-key": 2, "work": {
+								"title": "Symphony No. 5 in C minor, op. 67",
+								"relations": [
+									{"type": "parts", "direction": "forward", "target-type": "work", "work": {"title": "I"}},
+									{"type": "parts", "direction": "forward", "target-type": "work", "work": {"title": "II"}},
+									{"type": "parts", "direction": "forward", "target-type": "work", "work": {"title": "III"}},
+									{"type": "parts", "direction": "forward", "target-type": "work", "work": {"title": "IV"}}
+								]
+							}}
+						]
+					}}
+				]
+			}
+		}]}]
+	}`
+
+	var release musicbrainz.Release
+	require.NoError(t, json.Unmarshal([]byte(releaseJSON), &release))
+	trk := musicbrainz.FlatTracks(release.Media)[0]
+
+	got := ReleaseTags(&release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{Classical: true})
+	assert.Equal(t, "Symphony No. 5 in C minor, op. 67", got.Get(tags.Work))
+	assert.Equal(t, "Andante con moto", got.Get(tags.MovementName))
+	assert.Equal(t, "2", got.Get(tags.Movement))
+	assert.Equal(t, "4", got.Get(tags.MovementTotal))
+	assert.Equal(t, "Ludwig van Beethoven", got.Get(tags.Composer))
+	assert.Empty(t, got.Get(tags.Conductor)) // not enabled
+
+	got = ReleaseTags(&release, musicbrainz.LabelInfo{}, nil, 0, &trk, Options{})
+	assert.Empty(t, got.Get(tags.Work))
+}
+
+func TestMovementParts(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		title, parent string
+		position      int
+		name, number  string
+	}{
+		{"Symphony No. 5: I. Allegro con brio", "Symphony No. 5", 1, "Allegro con brio", "1"},
+		{"Symphony No. 5: IV. Allegro", "Symphony No. 5", 0, "Allegro", "4"},
+		{"Symphony No. 9: XIV. Finale", "Symphony No. 9", 0, "Finale", "14"},
+		{"Die Zauberflöte, K. 620: Act I, No. 1. Introduktion", "Die Zauberflöte, K. 620", 0, "Act I, No. 1. Introduktion", ""},
+		{"Air on the G String", "Orchestral Suite No. 3", 2, "Air on the G String", "2"},
+		{"3. Sarabande", "Cello Suite No. 1", 0, "Sarabande", "3"},
+	} {
+		name, number := movementParts(tc.title, tc.parent, tc.position)
+		assert.Equal(t, tc.name, name, tc.title)
+		assert.Equal(t, tc.number, number, tc.title)
+	}
+}
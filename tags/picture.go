@@ -0,0 +1,316 @@
+package tags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrPicturesUnsupported = errors.New("pictures not supported for this format")
+
+// PictureType is the ID3v2 APIC picture type, which FLAC uses too.
+type PictureType uint32
+
+const (
+	PictureOther      PictureType = 0
+	PictureFrontCover PictureType = 3
+	PictureBackCover  PictureType = 4
+)
+
+// Picture is an embedded picture, such as a front cover.
+type Picture struct {
+	Type          PictureType
+	MIMEType      string
+	Description   string
+	Width, Height int
+	Data          []byte
+}
+
+// CanWritePictures reports whether pictures can be written to the file. Currently FLAC, MP3, and MP4 are supported.
+func CanWritePictures(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac", ".mp3", ".m4a", ".m4b":
+		return true
+	}
+	return false
+}
+
+// CanReadPictures reports whether pictures can be read from the file. Currently FLAC, MP3, and MP4 are supported.
+func CanReadPictures(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac", ".mp3", ".m4a", ".m4b":
+		return true
+	}
+	return false
+}
+
+// ReadPictures returns the pictures embedded in the file. The dimensions are only known for FLAC.
+func ReadPictures(path string) ([]Picture, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		return readFLACPictures(path)
+	case ".mp3":
+		return readID3Pictures(path)
+	case ".m4a", ".m4b":
+		return readMP4Pictures(path)
+	}
+	return nil, ErrPicturesUnsupported
+}
+
+func readFLACPictures(path string) ([]Picture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	blocks, err := readFLACBlocks(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("read flac blocks: %w", err)
+	}
+
+	var pics []Picture
+	for _, b := range blocks {
+		if b.typ != flacBlockPicture {
+			continue
+		}
+		pic, err := decodeFLACPicture(b.data)
+		if err != nil {
+			return nil, fmt.Errorf("decode picture: %w", err)
+		}
+		pics = append(pics, pic)
+	}
+	return pics, nil
+}
+
+// WritePictures embeds the pictures in the file, after any existing ones. If replace is set, the existing ones are
+// removed first. MP4 has no picture types or descriptions, so only the data is written for it.
+func WritePictures(path string, pics []Picture, replace bool) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".flac":
+		return writeFLACPictures(path, pics, replace)
+	case ".mp3":
+		return writeID3Pictures(path, pics, replace)
+	case ".m4a", ".m4b":
+		return writeMP4Pictures(path, pics, replace)
+	}
+	return ErrPicturesUnsupported
+}
+
+func writeFLACPictures(path string, pics []Picture, replace bool) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer src.Close()
+
+	br := bufio.NewReader(src)
+	blocks, err := readFLACBlocks(br)
+	if err != nil {
+		return fmt.Errorf("read flac blocks: %w", err)
+	}
+
+	// streaminfo must stay first, and padding is moved last
+	var out []flacBlock
+	var padding []flacBlock
+	for _, b := range blocks {
+		switch {
+		case b.typ == flacBlockPadding:
+			padding = append(padding, b)
+		case b.typ == flacBlockPicture && replace:
+		default:
+			out = append(out, b)
+		}
+	}
+	for _, pic := range pics {
+		data, err := encodeFLACPicture(pic)
+		if err != nil {
+			return fmt.Errorf("encode picture: %w", err)
+		}
+		out = append(out, flacBlock{typ: flacBlockPicture, data: data})
+	}
+	out = append(out, padding...)
+
+	return rewriteFile(src, func(w io.Writer) error {
+		if err := writeFLACBlocks(w, out); err != nil {
+			return fmt.Errorf("write flac blocks: %w", err)
+		}
+		if _, err := io.Copy(w, br); err != nil {
+			return fmt.Errorf("copy audio: %w", err)
+		}
+		return nil
+	})
+}
+
+// rewriteFile replaces the open file with what write writes, through a temporary file next to it so that the file
+// is left alone if anything fails.
+func rewriteFile(f *os.File, write func(w io.Writer) error) error {
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.Name()), ".wrtag-picture-*")
+	if err != nil {
+		return fmt.Errorf("create temp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	bw := bufio.NewWriter(tmp)
+	if err := write(bw); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("flush: %w", err)
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		return fmt.Errorf("chmod: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close temp: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), f.Name()); err != nil {
+		return fmt.Errorf("replace file: %w", err)
+	}
+	return nil
+}
+
+// https://xiph.org/flac/format.html#metadata_block
+
+var flacMagic = []byte("fLaC")
+
+const (
+	flacBlockPadding = 1
+	flacBlockPicture = 6
+
+	flacBlockMaxLen = 1<<24 - 1
+)
+
+type flacBlock struct {
+	typ  byte
+	data []byte
+}
+
+func readFLACBlocks(r io.Reader) ([]flacBlock, error) {
+	magic := make([]byte, len(flacMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("read magic: %w", err)
+	}
+	if !bytes.Equal(magic, flacMagic) {
+		return nil, fmt.Errorf("not a flac stream, or has an id3v2 header")
+	}
+
+	var blocks []flacBlock
+	for {
+		var header [4]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("read block header: %w", err)
+		}
+		last := header[0]&0x80 != 0
+		typ := header[0] & 0x7f
+		size := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, fmt.Errorf("read block: %w", err)
+		}
+		blocks = append(blocks, flacBlock{typ: typ, data: data})
+		if last {
+			return blocks, nil
+		}
+	}
+}
+
+func writeFLACBlocks(w io.Writer, blocks []flacBlock) error {
+	if _, err := w.Write(flacMagic); err != nil {
+		return err
+	}
+	for i, b := range blocks {
+		if len(b.data) > flacBlockMaxLen {
+			return fmt.Errorf("block of %d bytes is too large", len(b.data))
+		}
+		header := [4]byte{b.typ, byte(len(b.data) >> 16), byte(len(b.data) >> 8), byte(len(b.data))}
+		if i == len(blocks)-1 {
+			header[0] |= 0x80
+		}
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := w.Write(b.data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeFLACPicture(data []byte) (Picture, error) {
+	r := bytes.NewReader(data)
+	readUint := func() (uint32, error) {
+		var v uint32
+		err := binary.Read(r, binary.BigEndian, &v)
+		return v, err
+	}
+	readBytes := func() ([]byte, error) {
+		n, err := readUint()
+		if err != nil {
+			return nil, err
+		}
+		if int64(n) > int64(r.Len()) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		b := make([]byte, n)
+		_, err = io.ReadFull(r, b)
+		return b, err
+	}
+
+	var pic Picture
+	var fields [4]uint32 // width, height, depth, colours
+	typ, err := readUint()
+	if err != nil {
+		return Picture{}, fmt.Errorf("read type: %w", err)
+	}
+	mime, err := readBytes()
+	if err != nil {
+		return Picture{}, fmt.Errorf("read mime type: %w", err)
+	}
+	desc, err := readBytes()
+	if err != nil {
+		return Picture{}, fmt.Errorf("read description: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &fields); err != nil {
+		return Picture{}, fmt.Errorf("read dimensions: %w", err)
+	}
+	pic.Data, err = readBytes()
+	if err != nil {
+		return Picture{}, fmt.Errorf("read data: %w", err)
+	}
+	pic.Type = PictureType(typ)
+	pic.MIMEType = string(mime)
+	pic.Description = string(desc)
+	pic.Width, pic.Height = int(fields[0]), int(fields[1])
+	return pic, nil
+}
+
+func encodeFLACPicture(pic Picture) ([]byte, error) {
+	var buf bytes.Buffer
+	writeBytes := func(b []byte) {
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(b)))
+		buf.Write(b)
+	}
+	_ = binary.Write(&buf, binary.BigEndian, uint32(pic.Type))
+	writeBytes([]byte(pic.MIMEType))
+	writeBytes([]byte(pic.Description))
+	_ = binary.Write(&buf, binary.BigEndian, [4]uint32{uint32(pic.Width), uint32(pic.Height), 24, 0})
+	writeBytes(pic.Data)
+	if buf.Len() > flacBlockMaxLen {
+		return nil, fmt.Errorf("picture of %d bytes is too large", len(pic.Data))
+	}
+	return buf.Bytes(), nil
+}
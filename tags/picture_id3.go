@@ -0,0 +1,325 @@
+package tags
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"unicode/utf16"
+)
+
+// https://id3.org/id3v2.4.0-structure and https://id3.org/id3v2.3.0
+
+func readID3Pictures(path string) ([]Picture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	tag, err := readID3Tag(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+
+	var pics []Picture
+	for _, frame := range tag.frames {
+		if frame.id != "APIC" {
+			continue
+		}
+		data, ok := frame.contents(tag.version)
+		if !ok {
+			continue // compressed or encrypted
+		}
+		pic, err := decodeAPIC(data)
+		if err != nil {
+			return nil, fmt.Errorf("decode picture: %w", err)
+		}
+		pics = append(pics, pic)
+	}
+	return pics, nil
+}
+
+func writeID3Pictures(path string, pics []Picture, replace bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	tag, err := readID3Tag(r)
+	if err != nil {
+		return err
+	}
+	switch tag.version {
+	case 0:
+		tag.version = 4
+	case 2:
+		return fmt.Errorf("%w: id3v2.2", ErrPicturesUnsupported)
+	}
+
+	if replace {
+		tag.frames = slices.DeleteFunc(tag.frames, func(f id3Frame) bool { return f.id == "APIC" })
+	}
+	for _, pic := range pics {
+		tag.frames = append(tag.frames, id3Frame{id: "APIC", data: encodeAPIC(pic, tag.version)})
+	}
+
+	data, err := encodeID3Tag(tag)
+	if err != nil {
+		return fmt.Errorf("encode tag: %w", err)
+	}
+	return rewriteFile(f, func(w io.Writer) error {
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("write tag: %w", err)
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return fmt.Errorf("copy audio: %w", err)
+		}
+		return nil
+	})
+}
+
+// id3Tag is an ID3v2.3 or ID3v2.4 tag. The version is 0 if there was no tag, and frames are only read for 3 and 4.
+type id3Tag struct {
+	version byte
+	frames  []id3Frame
+}
+
+// id3Frame is a frame as it's stored, so that it can be written back without knowing about its flags.
+type id3Frame struct {
+	id    string
+	flags [2]byte
+	data  []byte
+}
+
+// contents returns the frame's data without any unsynchronisation or data length indicator, or false if it's
+// compressed or encrypted.
+func (f id3Frame) contents(version byte) ([]byte, bool) {
+	data := f.data
+	if version == 3 {
+		return data, f.flags[1]&0xc0 == 0
+	}
+	if f.flags[1]&0x0c != 0 {
+		return nil, false
+	}
+	if f.flags[1]&0x01 != 0 && len(data) >= 4 {
+		data = data[4:] // data length indicator
+	}
+	if f.flags[1]&0x02 != 0 {
+		data = unsync(data)
+	}
+	return data, true
+}
+
+// readID3Tag reads the tag at the start of r, if there is one, leaving r at the audio after it.
+func readID3Tag(r *bufio.Reader) (id3Tag, error) {
+	header, err := r.Peek(10)
+	if err != nil || string(header[:3]) != "ID3" {
+		return id3Tag{}, nil // too short for a tag, or no tag
+	}
+	version, flags, size := header[3], header[5], syncsafe(header[6:10])
+
+	total := 10 + size
+	if version == 4 && flags&0x10 != 0 {
+		total += 10 // footer
+	}
+	tag := make([]byte, total)
+	if _, err := io.ReadFull(r, tag); err != nil {
+		return id3Tag{}, fmt.Errorf("read tag: %w", err)
+	}
+	tag = tag[10 : 10+size]
+
+	if version != 3 && version != 4 {
+		return id3Tag{version: version}, nil // v2.2 has different frames, and isn't worth supporting
+	}
+	if version == 3 && flags&0x80 != 0 {
+		tag = unsync(tag)
+	}
+	if flags&0x40 != 0 {
+		// skip the extended header
+		if len(tag) < 4 {
+			return id3Tag{}, fmt.Errorf("short extended header")
+		}
+		size := int(binary.BigEndian.Uint32(tag)) + 4
+		if version == 4 {
+			size = syncsafe(tag[:4])
+		}
+		if size > len(tag) {
+			return id3Tag{}, fmt.Errorf("bad extended header size")
+		}
+		tag = tag[size:]
+	}
+
+	t := id3Tag{version: version}
+	for len(tag) >= 10 {
+		id := string(tag[:4])
+		if id == "\x00\x00\x00\x00" {
+			break // padding
+		}
+		size := int(binary.BigEndian.Uint32(tag[4:8]))
+		if version == 4 {
+			size = syncsafe(tag[4:8])
+		}
+		if size > len(tag)-10 {
+			return id3Tag{}, fmt.Errorf("frame %s is too large", id)
+		}
+		t.frames = append(t.frames, id3Frame{id: id, flags: [2]byte(tag[8:10]), data: tag[10 : 10+size]})
+		tag = tag[10+size:]
+	}
+	return t, nil
+}
+
+// id3Padding is left after the frames when writing a tag, so that taggers can add to it without rewriting the file.
+const id3Padding = 1024
+
+// encodeID3Tag encodes the tag without unsynchronisation, an extended header, or a footer.
+func encodeID3Tag(t id3Tag) ([]byte, error) {
+	var body bytes.Buffer
+	for _, f := range t.frames {
+		if len(f.data) >= 1<<28 {
+			return nil, fmt.Errorf("frame %s of %d bytes is too large", f.id, len(f.data))
+		}
+		body.WriteString(f.id)
+		if t.version == 4 {
+			body.Write(syncsafeBytes(len(f.data)))
+		} else {
+			_ = binary.Write(&body, binary.BigEndian, uint32(len(f.data)))
+		}
+		body.Write(f.flags[:])
+		body.Write(f.data)
+	}
+	body.Write(make([]byte, id3Padding))
+	if body.Len() >= 1<<28 {
+		return nil, fmt.Errorf("tag of %d bytes is too large", body.Len())
+	}
+
+	var b bytes.Buffer
+	b.WriteString("ID3")
+	b.Write([]byte{t.version, 0, 0})
+	b.Write(syncsafeBytes(body.Len()))
+	b.Write(body.Bytes())
+	return b.Bytes(), nil
+}
+
+func decodeAPIC(data []byte) (Picture, error) {
+	if len(data) < 1 {
+		return Picture{}, io.ErrUnexpectedEOF
+	}
+	encoding, data := data[0], data[1:]
+
+	mime, data, ok := bytes.Cut(data, []byte{0})
+	if !ok || len(data) < 1 {
+		return Picture{}, io.ErrUnexpectedEOF
+	}
+	typ, data := data[0], data[1:]
+
+	var desc []byte
+	switch encoding {
+	case 1, 2: // utf-16, terminated by two aligned zero bytes
+		i := 0
+		for ; i+1 < len(data) && (data[i] != 0 || data[i+1] != 0); i += 2 {
+		}
+		if i+1 >= len(data) {
+			return Picture{}, io.ErrUnexpectedEOF
+		}
+		desc, data = decodeUTF16(data[:i], encoding == 1), data[i+2:]
+	default:
+		desc, data, ok = bytes.Cut(data, []byte{0})
+		if !ok {
+			return Picture{}, io.ErrUnexpectedEOF
+		}
+	}
+
+	return Picture{
+		Type:        PictureType(typ),
+		MIMEType:    apicMIMEType(string(mime)),
+		Description: string(desc),
+		Data:        bytes.Clone(data),
+	}, nil
+}
+
+func encodeAPIC(pic Picture, version byte) []byte {
+	var b bytes.Buffer
+	var desc []byte
+	var term []byte
+	switch {
+	case isLatin1(pic.Description):
+		b.WriteByte(0)
+		desc, term = []byte(pic.Description), []byte{0}
+	case version == 4:
+		b.WriteByte(3) // utf-8
+		desc, term = []byte(pic.Description), []byte{0}
+	default:
+		b.WriteByte(1) // utf-16 with a bom
+		desc, term = encodeUTF16(pic.Description), []byte{0, 0}
+	}
+	b.WriteString(pic.MIMEType)
+	b.WriteByte(0)
+	b.WriteByte(byte(pic.Type))
+	b.Write(desc)
+	b.Write(term)
+	b.Write(pic.Data)
+	return b.Bytes()
+}
+
+// isLatin1 reports whether s can be written as latin-1 without conversion, which is only true for ASCII.
+func isLatin1(s string) bool {
+	for i := range len(s) {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// apicMIMEType fixes up the "JPG" or "PNG" that ID3v2.2 used, which some taggers still write.
+func apicMIMEType(mime string) string {
+	switch mime {
+	case "JPG", "jpg", "image/jpg":
+		return "image/jpeg"
+	case "PNG", "png":
+		return "image/png"
+	}
+	return mime
+}
+
+func decodeUTF16(b []byte, bom bool) []byte {
+	order := binary.ByteOrder(binary.BigEndian)
+	if bom && len(b) >= 2 {
+		if b[0] == 0xff && b[1] == 0xfe {
+			order = binary.LittleEndian
+		}
+		b = b[2:]
+	}
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, order.Uint16(b[i:]))
+	}
+	return []byte(string(utf16.Decode(u)))
+}
+
+func encodeUTF16(s string) []byte {
+	b := []byte{0xff, 0xfe}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func syncsafe(b []byte) int {
+	return int(b[0]&0x7f)<<21 | int(b[1]&0x7f)<<14 | int(b[2]&0x7f)<<7 | int(b[3]&0x7f)
+}
+
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+// unsync reverses the unsynchronisation scheme, where a zero byte is inserted after each 0xff.
+func unsync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xff, 0x00}, []byte{0xff})
+}
@@ -0,0 +1,365 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+)
+
+// Cover art in MP4 is in moov.udta.meta.ilst.covr, as one or more data atoms.
+// https://developer.apple.com/documentation/quicktime-file-format/metadata_item_list_atom
+
+var mp4CovrPath = []string{"moov", "udta", "meta", "ilst", "covr"}
+
+func readMP4Pictures(path string) ([]Picture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat: %w", err)
+	}
+
+	r := io.NewSectionReader(f, 0, info.Size())
+	for _, name := range mp4CovrPath {
+		r, err = findMP4Atom(r, name)
+		if err != nil {
+			return nil, fmt.Errorf("find %s: %w", name, err)
+		}
+		if r == nil {
+			return nil, nil
+		}
+		if name == "meta" {
+			// meta is a full atom, with a version and flags before its children
+			r = io.NewSectionReader(r, 4, max(0, r.Size()-4))
+		}
+	}
+
+	var pics []Picture
+	for off := int64(0); off < r.Size(); {
+		typ, start, size, err := readMP4AtomHeader(r, off)
+		if err != nil {
+			return nil, fmt.Errorf("read covr atom: %w", err)
+		}
+		off = start + size
+		if typ != "data" || size < 8 {
+			continue
+		}
+
+		data := make([]byte, size)
+		if _, err := r.ReadAt(data, start); err != nil {
+			return nil, fmt.Errorf("read data atom: %w", err)
+		}
+		// data atoms have a type indicator and a locale before the value
+		var mime string
+		switch binary.BigEndian.Uint32(data) & 0xffffff {
+		case 13:
+			mime = "image/jpeg"
+		case 14:
+			mime = "image/png"
+		case 27:
+			mime = "image/bmp"
+		}
+		pics = append(pics, Picture{Type: PictureFrontCover, MIMEType: mime, Data: data[8:]})
+	}
+	return pics, nil
+}
+
+func writeMP4Pictures(path string, pics []Picture, replace bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat: %w", err)
+	}
+
+	// moov is rewritten in memory, and the atoms around it, like mdat, are copied as they are
+	r := io.NewSectionReader(f, 0, info.Size())
+	var moovOff, moovEnd int64 = -1, -1
+	for off := int64(0); off < r.Size(); {
+		typ, start, size, err := readMP4AtomHeader(r, off)
+		if err != nil {
+			return fmt.Errorf("read atom: %w", err)
+		}
+		if typ == "moov" {
+			moovOff, moovEnd = off, start+size
+			break
+		}
+		off = start + size
+	}
+	if moovOff < 0 {
+		return fmt.Errorf("no moov atom")
+	}
+
+	moovData := make([]byte, moovEnd-moovOff)
+	if _, err := r.ReadAt(moovData, moovOff); err != nil {
+		return fmt.Errorf("read moov: %w", err)
+	}
+	atoms, err := parseMP4Atoms(moovData, "")
+	if err != nil {
+		return fmt.Errorf("parse moov: %w", err)
+	}
+	moov := atoms[0]
+
+	ilst := moov.
+		childOrNew("udta", nil).
+		childOrNew("meta", func() *mp4Atom {
+			return &mp4Atom{typ: "meta", data: make([]byte, 4), children: []*mp4Atom{{typ: "hdlr", data: mp4MetaHandler}}}
+		}).
+		childOrNew("ilst", nil)
+	covr := ilst.childOrNew("covr", nil)
+	if replace {
+		covr.children = nil
+	}
+	for _, pic := range pics {
+		covr.children = append(covr.children, &mp4Atom{typ: "data", data: encodeMP4Picture(pic)})
+	}
+
+	// the chunk offsets point into mdat, so they move with it if it's after moov
+	if delta := moov.size() - (moovEnd - moovOff); delta != 0 {
+		if err := shiftMP4ChunkOffsets(moov, moovOff, delta); err != nil {
+			return fmt.Errorf("shift chunk offsets: %w", err)
+		}
+	}
+
+	newMoov, err := moov.encode()
+	if err != nil {
+		return fmt.Errorf("encode moov: %w", err)
+	}
+	return rewriteFile(f, func(w io.Writer) error {
+		if _, err := io.Copy(w, io.NewSectionReader(f, 0, moovOff)); err != nil {
+			return fmt.Errorf("copy before moov: %w", err)
+		}
+		if _, err := w.Write(newMoov); err != nil {
+			return fmt.Errorf("write moov: %w", err)
+		}
+		if _, err := io.Copy(w, io.NewSectionReader(f, moovEnd, info.Size()-moovEnd)); err != nil {
+			return fmt.Errorf("copy after moov: %w", err)
+		}
+		return nil
+	})
+}
+
+// mp4MetaHandler is the hdlr for a meta atom with iTunes style metadata.
+var mp4MetaHandler = []byte("\x00\x00\x00\x00\x00\x00\x00\x00mdirappl\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+func encodeMP4Picture(pic Picture) []byte {
+	var indicator byte
+	switch pic.MIMEType {
+	case "image/jpeg":
+		indicator = 13
+	case "image/png":
+		indicator = 14
+	case "image/bmp":
+		indicator = 27
+	}
+	// a type indicator and a locale before the value
+	return append([]byte{0, 0, 0, indicator, 0, 0, 0, 0}, pic.Data...)
+}
+
+// mp4Atom is an atom in moov. The ones on the way to the metadata and the chunk offsets have their children parsed,
+// and the rest are kept as they are.
+type mp4Atom struct {
+	typ      string
+	data     []byte // contents, or the version and flags before the children of a full atom like meta
+	children []*mp4Atom
+}
+
+var mp4Containers = map[string]bool{
+	"moov": true, "moov.udta": true, "moov.udta.meta": true, "moov.udta.meta.ilst": true, "moov.udta.meta.ilst.covr": true,
+	"moov.trak": true, "moov.trak.mdia": true, "moov.trak.mdia.minf": true, "moov.trak.mdia.minf.stbl": true,
+}
+
+func parseMP4Atoms(b []byte, parent string) ([]*mp4Atom, error) {
+	r := io.NewSectionReader(bytes.NewReader(b), 0, int64(len(b)))
+	var atoms []*mp4Atom
+	for off := int64(0); off < r.Size(); {
+		typ, start, size, err := readMP4AtomHeader(r, off)
+		if err != nil {
+			return nil, err
+		}
+		off = start + size
+
+		body := b[start : start+size]
+		path := strings.TrimPrefix(parent+"."+typ, ".")
+		if !mp4Containers[path] {
+			atoms = append(atoms, &mp4Atom{typ: typ, data: body})
+			continue
+		}
+
+		a := &mp4Atom{typ: typ}
+		if typ == "meta" {
+			if len(body) < 4 {
+				return nil, fmt.Errorf("short meta atom")
+			}
+			a.data, body = body[:4], body[4:]
+		}
+		if a.children, err = parseMP4Atoms(body, path); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", typ, err)
+		}
+		atoms = append(atoms, a)
+	}
+	return atoms, nil
+}
+
+// childOrNew returns the first child with the type. If there isn't one, one is added from newAtom, or an empty one if
+// newAtom is nil.
+func (a *mp4Atom) childOrNew(typ string, newAtom func() *mp4Atom) *mp4Atom {
+	for _, c := range a.children {
+		if c.typ == typ {
+			return c
+		}
+	}
+	c := &mp4Atom{typ: typ}
+	if newAtom != nil {
+		c = newAtom()
+	}
+	a.children = append(a.children, c)
+	return c
+}
+
+func (a *mp4Atom) size() int64 {
+	size := int64(8 + len(a.data))
+	for _, c := range a.children {
+		size += c.size()
+	}
+	return size
+}
+
+func (a *mp4Atom) encode() ([]byte, error) {
+	size := a.size()
+	if size > math.MaxUint32 {
+		return nil, fmt.Errorf("atom %q of %d bytes is too large", a.typ, size)
+	}
+	b := make([]byte, 0, size)
+	b = binary.BigEndian.AppendUint32(b, uint32(size))
+	b = append(b, a.typ...)
+	b = append(b, a.data...)
+	for _, c := range a.children {
+		cb, err := c.encode()
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, cb...)
+	}
+	return b, nil
+}
+
+// shiftMP4ChunkOffsets moves the chunk offsets in each track that point after moov by delta.
+func shiftMP4ChunkOffsets(moov *mp4Atom, moovOff, delta int64) error {
+	for _, trak := range moov.children {
+		if trak.typ != "trak" {
+			continue
+		}
+		for _, stbl := range mp4Descendants(trak, "mdia", "minf", "stbl") {
+			for _, a := range stbl.children {
+				var width int
+				switch a.typ {
+				case "stco":
+					width = 4
+				case "co64":
+					width = 8
+				default:
+					continue
+				}
+				if len(a.data) < 8 {
+					return fmt.Errorf("short %s atom", a.typ)
+				}
+				data := bytes.Clone(a.data)
+				count := int(binary.BigEndian.Uint32(data[4:8]))
+				if count > (len(data)-8)/width {
+					return fmt.Errorf("%s atom has a bad entry count", a.typ)
+				}
+				for i := range count {
+					entry := data[8+i*width:]
+					switch width {
+					case 4:
+						v := int64(binary.BigEndian.Uint32(entry))
+						if v < moovOff {
+							continue
+						}
+						if v+delta > math.MaxUint32 {
+							return fmt.Errorf("chunk offset overflows stco")
+						}
+						binary.BigEndian.PutUint32(entry, uint32(v+delta))
+					case 8:
+						v := int64(binary.BigEndian.Uint64(entry))
+						if v < moovOff {
+							continue
+						}
+						binary.BigEndian.PutUint64(entry, uint64(v+delta))
+					}
+				}
+				a.data = data
+			}
+		}
+	}
+	return nil
+}
+
+// mp4Descendants returns the atoms found by following the path of types down from a.
+func mp4Descendants(a *mp4Atom, path ...string) []*mp4Atom {
+	if len(path) == 0 {
+		return []*mp4Atom{a}
+	}
+	var found []*mp4Atom
+	for _, c := range a.children {
+		if c.typ == path[0] {
+			found = append(found, mp4Descendants(c, path[1:]...)...)
+		}
+	}
+	return found
+}
+
+// findMP4Atom returns the contents of the first atom with the type in r, or nil if there isn't one.
+func findMP4Atom(r *io.SectionReader, typ string) (*io.SectionReader, error) {
+	for off := int64(0); off < r.Size(); {
+		t, start, size, err := readMP4AtomHeader(r, off)
+		if err != nil {
+			return nil, err
+		}
+		if t == typ {
+			return io.NewSectionReader(r, start, size), nil
+		}
+		off = start + size
+	}
+	return nil, nil
+}
+
+// readMP4AtomHeader reads the header of the atom at off, and returns its type and where its contents are.
+func readMP4AtomHeader(r *io.SectionReader, off int64) (typ string, start, size int64, err error) {
+	var header [8]byte
+	if _, err := r.ReadAt(header[:], off); err != nil {
+		return "", 0, 0, fmt.Errorf("read header: %w", err)
+	}
+	typ = string(header[4:])
+	start = off + 8
+	switch atomSize := int64(binary.BigEndian.Uint32(header[:4])); atomSize {
+	case 0: // until the end
+		size = r.Size() - start
+	case 1: // 64 bit size after the type
+		var ext [8]byte
+		if _, err := r.ReadAt(ext[:], start); err != nil {
+			return "", 0, 0, fmt.Errorf("read extended size: %w", err)
+		}
+		start += 8
+		size = int64(binary.BigEndian.Uint64(ext[:])) - 16
+	default:
+		size = atomSize - 8
+	}
+	if size < 0 || start+size > r.Size() {
+		return "", 0, 0, fmt.Errorf("atom %q has a bad size", typ)
+	}
+	return typ, start, size, nil
+}
@@ -0,0 +1,266 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPictures(t *testing.T) {
+	t.Parallel()
+
+	path := newFile(t, emptyFLAC, ".flac")
+	require.NoError(t, WriteTags(path, NewTags(Title, "The Bells")))
+
+	pics, err := ReadPictures(path)
+	require.NoError(t, err)
+	assert.Empty(t, pics)
+
+	front := Picture{Type: PictureFrontCover, MIMEType: "image/jpeg", Width: 2, Height: 3, Data: []byte("front")}
+	back := Picture{Type: PictureBackCover, MIMEType: "image/png", Description: "back", Data: []byte("back")}
+
+	require.NoError(t, WritePictures(path, []Picture{front}, false))
+	require.NoError(t, WritePictures(path, []Picture{back}, false))
+
+	pics, err = ReadPictures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Picture{front, back}, pics)
+
+	require.NoError(t, WritePictures(path, []Picture{front}, true))
+
+	pics, err = ReadPictures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Picture{front}, pics)
+
+	// tags and audio are untouched
+	tg, err := ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, "The Bells", tg.Get(Title))
+	_, err = ReadProperties(path)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, WritePictures(newFile(t, nil, ".wav"), []Picture{front}, false), ErrPicturesUnsupported)
+	_, err = ReadPictures(newFile(t, nil, ".wav"))
+	require.ErrorIs(t, err, ErrPicturesUnsupported)
+}
+
+func TestWritePicturesMP3MP4(t *testing.T) {
+	t.Parallel()
+
+	front := Picture{Type: PictureFrontCover, MIMEType: "image/jpeg", Data: []byte("front")}
+	back := Picture{Type: PictureBackCover, MIMEType: "image/png", Description: "back", Data: []byte("back")}
+
+	for _, c := range []struct {
+		ext  string
+		data []byte
+		want []Picture
+	}{
+		{".mp3", emptyMP3, []Picture{front, back}},
+		{".m4a", emptyM4A, []Picture{front, {Type: PictureFrontCover, MIMEType: "image/png", Data: []byte("back")}}}, // no types or descriptions
+	} {
+		path := newFile(t, c.data, c.ext)
+		require.NoError(t, WriteTags(path, NewTags(Title, "The Bells")))
+
+		require.NoError(t, WritePictures(path, []Picture{front}, false), c.ext)
+		require.NoError(t, WritePictures(path, []Picture{back}, false), c.ext)
+
+		pics, err := ReadPictures(path)
+		require.NoError(t, err, c.ext)
+		assert.Equal(t, c.want, pics, c.ext)
+
+		require.NoError(t, WritePictures(path, []Picture{front}, true), c.ext)
+
+		pics, err = ReadPictures(path)
+		require.NoError(t, err, c.ext)
+		assert.Equal(t, c.want[:1], pics, c.ext)
+
+		// tags and audio are untouched
+		tg, err := ReadTags(path)
+		require.NoError(t, err, c.ext)
+		assert.Equal(t, "The Bells", tg.Get(Title), c.ext)
+		_, err = ReadProperties(path)
+		require.NoError(t, err, c.ext)
+	}
+
+	// a file without a tag gets one
+	path := newFile(t, emptyMP3, ".mp3")
+	require.NoError(t, WritePictures(path, []Picture{front}, false))
+	pics, err := ReadPictures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Picture{front}, pics)
+}
+
+func TestWriteID3v23Pictures(t *testing.T) {
+	t.Parallel()
+
+	// a v2.3 tag with a title frame, from a tagger other than taglib
+	title := append([]byte("TIT2\x00\x00\x00\x0a\x00\x00"), "\x00The Bells"...)
+	var tag bytes.Buffer
+	tag.WriteString("ID3\x03\x00\x00")
+	tag.Write(syncsafeBytes(len(title)))
+	tag.Write(title)
+	path := newFile(t, append(tag.Bytes(), emptyMP3...), ".mp3")
+
+	// descriptions that aren't latin-1 are written as utf-16, since v2.3 has no utf-8
+	pic := Picture{Type: PictureFrontCover, MIMEType: "image/jpeg", Description: "前", Data: []byte("front")}
+	require.NoError(t, WritePictures(path, []Picture{pic}, false))
+
+	pics, err := ReadPictures(path)
+	require.NoError(t, err)
+	assert.Equal(t, []Picture{pic}, pics)
+
+	tg, err := ReadTags(path)
+	require.NoError(t, err)
+	assert.Equal(t, "The Bells", tg.Get(Title))
+}
+
+func TestWriteMP4PicturesChunkOffsets(t *testing.T) {
+	t.Parallel()
+
+	atom := func(typ string, children ...[]byte) []byte {
+		body := bytes.Join(children, nil)
+		var b bytes.Buffer
+		_ = binary.Write(&b, binary.BigEndian, uint32(8+len(body)))
+		b.WriteString(typ)
+		b.Write(body)
+		return b.Bytes()
+	}
+	stco := func(offset uint32) []byte {
+		return atom("stco", []byte{0, 0, 0, 0, 0, 0, 0, 1}, binary.BigEndian.AppendUint32(nil, offset))
+	}
+
+	// moov before mdat, so the chunk offset has to move along with the audio
+	ftyp := atom("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	moovLen := len(atom("moov", atom("trak", atom("mdia", atom("minf", atom("stbl", stco(0)))))))
+	audioOff := len(ftyp) + moovLen + 8
+	file := bytes.Join([][]byte{
+		ftyp,
+		atom("moov", atom("trak", atom("mdia", atom("minf", atom("stbl", stco(uint32(audioOff))))))),
+		atom("mdat", []byte("audio")),
+	}, nil)
+	path := newFile(t, file, ".m4a")
+
+	require.NoError(t, WritePictures(path, []Picture{{Type: PictureFrontCover, MIMEType: "image/jpeg", Data: []byte("front")}}, false))
+
+	pics, err := ReadPictures(path)
+	require.NoError(t, err)
+	require.Len(t, pics, 1)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	i := bytes.Index(data, []byte("stco"))
+	require.Positive(t, i)
+	offset := binary.BigEndian.Uint32(data[i+12:])
+	assert.Equal(t, "audio", string(data[offset:offset+5]))
+}
+
+func TestReadID3Pictures(t *testing.T) {
+	t.Parallel()
+
+	frame := func(version byte, id string, data []byte) []byte {
+		var b bytes.Buffer
+		b.WriteString(id)
+		if version == 4 {
+			b.Write(syncsafeBytes(len(data)))
+		} else {
+			_ = binary.Write(&b, binary.BigEndian, uint32(len(data)))
+		}
+		b.Write([]byte{0, 0})
+		b.Write(data)
+		return b.Bytes()
+	}
+	tag := func(version byte, frames ...[]byte) []byte {
+		body := bytes.Join(frames, nil)
+		body = append(body, make([]byte, 16)...) // padding
+		var b bytes.Buffer
+		b.WriteString("ID3")
+		b.Write([]byte{version, 0, 0})
+		b.Write(syncsafeBytes(len(body)))
+		b.Write(body)
+		return b.Bytes()
+	}
+
+	// latin-1 description
+	apicFront := append([]byte("\x00image/jpeg\x00\x03front\x00"), 0xff, 0xd8, 0xff)
+	// utf-16 description with a bom, and the old style mime type
+	apicBack := append([]byte("\x01PNG\x00\x04\xff\xfeb\x00k\x00\x00\x00"), []byte("png")...)
+
+	for _, version := range []byte{3, 4} {
+		data := tag(version,
+			frame(version, "TIT2", []byte("\x00The Bells")),
+			frame(version, "APIC", apicFront),
+			frame(version, "APIC", apicBack),
+		)
+		path := newFile(t, append(data, emptyMP3...), ".mp3")
+
+		pics, err := ReadPictures(path)
+		require.NoError(t, err)
+		assert.Equal(t, []Picture{
+			{Type: PictureFrontCover, MIMEType: "image/jpeg", Description: "front", Data: []byte{0xff, 0xd8, 0xff}},
+			{Type: PictureBackCover, MIMEType: "image/png", Description: "bk", Data: []byte("png")},
+		}, pics, "version %d", version)
+	}
+
+	// tagged by taglib, without any pictures
+	path := newFile(t, emptyMP3, ".mp3")
+	require.NoError(t, WriteTags(path, NewTags(Title, "The Bells")))
+	pics, err := ReadPictures(path)
+	require.NoError(t, err)
+	assert.Empty(t, pics)
+
+	// no tag at all
+	pics, err = ReadPictures(newFile(t, []byte("not an mp3"), ".mp3"))
+	require.NoError(t, err)
+	assert.Empty(t, pics)
+}
+
+func TestReadMP4Pictures(t *testing.T) {
+	t.Parallel()
+
+	atom := func(typ string, children ...[]byte) []byte {
+		body := bytes.Join(children, nil)
+		var b bytes.Buffer
+		_ = binary.Write(&b, binary.BigEndian, uint32(8+len(body)))
+		b.WriteString(typ)
+		b.Write(body)
+		return b.Bytes()
+	}
+	data := func(indicator byte, value string) []byte {
+		return atom("data", []byte{0, 0, 0, indicator, 0, 0, 0, 0}, []byte(value))
+	}
+
+	file := bytes.Join([][]byte{
+		atom("ftyp", []byte("M4A \x00\x00\x00\x00")),
+		atom("mdat", []byte("audio")),
+		atom("moov",
+			atom("mvhd", make([]byte, 12)),
+			atom("udta",
+				atom("meta", []byte{0, 0, 0, 0},
+					atom("hdlr", make([]byte, 12)),
+					atom("ilst",
+						atom("\xa9nam", data(1, "The Bells")),
+						atom("covr", data(13, "jpeg"), data(14, "png")),
+					),
+				),
+			),
+		),
+	}, nil)
+
+	pics, err := ReadPictures(newFile(t, file, ".m4a"))
+	require.NoError(t, err)
+	assert.Equal(t, []Picture{
+		{Type: PictureFrontCover, MIMEType: "image/jpeg", Data: []byte("jpeg")},
+		{Type: PictureFrontCover, MIMEType: "image/png", Data: []byte("png")},
+	}, pics)
+
+	// tagged by taglib, without any pictures
+	path := newFile(t, emptyM4A, ".m4a")
+	require.NoError(t, WriteTags(path, NewTags(Title, "The Bells")))
+	pics, err = ReadPictures(path)
+	require.NoError(t, err)
+	assert.Empty(t, pics)
+}
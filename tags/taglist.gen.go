@@ -4,47 +4,77 @@ var knownTags = map[string]struct{}{
 	"ALBUM": {},
 	"ALBUMARTIST": {},
 	"ALBUMARTISTS": {},
+	"ALBUMARTISTSORT": {},
 	"ALBUMARTISTS_CREDIT": {},
 	"ALBUMARTIST_CREDIT": {},
+	"ARRANGER": {},
 	"ARTIST": {},
 	"ARTISTS": {},
+	"ARTISTSORT": {},
 	"ARTISTS_CREDIT": {},
 	"ARTIST_CREDIT": {},
+	"ASIN": {},
+	"BARCODE": {},
 	"CATALOGNUMBER": {},
 	"COMPILATION": {},
+	"COMPOSER": {},
+	"CONDUCTOR": {},
 	"DATE": {},
 	"DISCNUMBER": {},
+	"ENGINEER": {},
 	"GENRE": {},
 	"GENRES": {},
+	"ISRC": {},
 	"LABEL": {},
+	"LANGUAGE": {},
+	"LYRICIST": {},
 	"LYRICS": {},
 	"MEDIA": {},
+	"MIXER": {},
+	"MOVEMENT": {},
+	"MOVEMENTNAME": {},
+	"MOVEMENTTOTAL": {},
 	"MUSICBRAINZ_ALBUMARTISTID": {},
 	"MUSICBRAINZ_ALBUMCOMMENT": {},
 	"MUSICBRAINZ_ALBUMID": {},
 	"MUSICBRAINZ_ARTISTID": {},
 	"MUSICBRAINZ_RELEASEGROUPID": {},
+	"MUSICBRAINZ_RELEASETRACKID": {},
 	"MUSICBRAINZ_TRACKID": {},
 	"ORIGINALDATE": {},
+	"ORIGINALYEAR": {},
+	"PERFORMER": {},
+	"PRODUCER": {},
+	"RELEASECOUNTRY": {},
+	"RELEASESTATUS": {},
+	"RELEASETYPE": {},
+	"REMIXER": {},
 	"REPLAYGAIN_ALBUM_GAIN": {},
 	"REPLAYGAIN_ALBUM_PEAK": {},
 	"REPLAYGAIN_TRACK_GAIN": {},
 	"REPLAYGAIN_TRACK_PEAK": {},
+	"SCRIPT": {},
 	"TITLE": {},
 	"TRACKNUMBER": {},
 	"UPC": {},
+	"WORK": {},
+	"WRITER": {},
 }
 var alternatives = map[string]string{
 	"ALBUM_ARTIST": "ALBUMARTIST",
 	"ALBUM ARTIST": "ALBUMARTIST",
 	"ALBUM_ARTISTS": "ALBUMARTISTS",
 	"ALBUM ARTISTS": "ALBUMARTISTS",
+	"ALBUM_ARTIST_SORT": "ALBUMARTISTSORT",
+	"ALBUM ARTIST SORT": "ALBUMARTISTSORT",
 	"ALBUMARTISTS CREDIT": "ALBUMARTISTS_CREDIT",
 	"ALBUM_ARTISTS_CREDIT": "ALBUMARTISTS_CREDIT",
 	"ALBUM ARTISTS CREDIT": "ALBUMARTISTS_CREDIT",
 	"ALBUMARTIST CREDIT": "ALBUMARTIST_CREDIT",
 	"ALBUM_ARTIST_CREDIT": "ALBUMARTIST_CREDIT",
 	"ALBUM ARTIST CREDIT": "ALBUMARTIST_CREDIT",
+	"ARTIST_SORT": "ARTISTSORT",
+	"ARTIST SORT": "ARTISTSORT",
 	"ARTISTS CREDIT": "ARTISTS_CREDIT",
 	"ARTISTSCREDIT": "ARTISTS_CREDIT",
 	"ARTIST CREDIT": "ARTIST_CREDIT",
@@ -54,14 +84,34 @@ var alternatives = map[string]string{
 	"LYRICS:DESCRIPTION": "LYRICS",
 	"USLT:DESCRIPTION": "LYRICS",
 	"©LYR": "LYRICS",
+	"MOVEMENTNUMBER": "MOVEMENT",
+	"MOVEMENTCOUNT": "MOVEMENTTOTAL",
 	"MUSICBRAINZ ALBUMARTISTID": "MUSICBRAINZ_ALBUMARTISTID",
 	"MUSICBRAINZ ALBUMCOMMENT": "MUSICBRAINZ_ALBUMCOMMENT",
 	"MUSICBRAINZ ALBUMID": "MUSICBRAINZ_ALBUMID",
 	"MUSICBRAINZ ARTISTID": "MUSICBRAINZ_ARTISTID",
 	"MUSICBRAINZ RELEASEGROUPID": "MUSICBRAINZ_RELEASEGROUPID",
+	"MUSICBRAINZ RELEASETRACKID": "MUSICBRAINZ_RELEASETRACKID",
+	"MUSICBRAINZ_RELEASE_TRACK_ID": "MUSICBRAINZ_RELEASETRACKID",
+	"MUSICBRAINZ RELEASE TRACK ID": "MUSICBRAINZ_RELEASETRACKID",
 	"MUSICBRAINZ TRACKID": "MUSICBRAINZ_TRACKID",
-	"ORIGINAL_YEAR": "ORIGINALDATE",
-	"ORIGINAL YEAR": "ORIGINALDATE",
+	"ORIGINAL_DATE": "ORIGINALDATE",
+	"ORIGINAL DATE": "ORIGINALDATE",
+	"ORIGINAL_YEAR": "ORIGINALYEAR",
+	"ORIGINAL YEAR": "ORIGINALYEAR",
+	"MUSICBRAINZ_ALBUM_RELEASE_COUNTRY": "RELEASECOUNTRY",
+	"MUSICBRAINZ ALBUM RELEASE COUNTRY": "RELEASECOUNTRY",
+	"RELEASE_COUNTRY": "RELEASECOUNTRY",
+	"RELEASE COUNTRY": "RELEASECOUNTRY",
+	"MUSICBRAINZ_ALBUM_STATUS": "RELEASESTATUS",
+	"MUSICBRAINZ ALBUM STATUS": "RELEASESTATUS",
+	"MUSICBRAINZ_ALBUMSTATUS": "RELEASESTATUS",
+	"MUSICBRAINZ ALBUMSTATUS": "RELEASESTATUS",
+	"MUSICBRAINZ_ALBUM_TYPE": "RELEASETYPE",
+	"MUSICBRAINZ ALBUM TYPE": "RELEASETYPE",
+	"MUSICBRAINZ_ALBUMTYPE": "RELEASETYPE",
+	"MUSICBRAINZ ALBUMTYPE": "RELEASETYPE",
+	"MIXARTIST": "REMIXER",
 	"REPLAYGAIN ALBUM GAIN": "REPLAYGAIN_ALBUM_GAIN",
 	"REPLAYGAIN ALBUM PEAK": "REPLAYGAIN_ALBUM_PEAK",
 	"REPLAYGAIN TRACK GAIN": "REPLAYGAIN_TRACK_GAIN",
@@ -21,13 +21,22 @@ const (
 	AlbumArtists       = "ALBUMARTISTS"        //tag: alts "ALBUM_ARTISTS"
 	AlbumArtistCredit  = "ALBUMARTIST_CREDIT"  //tag: alts "ALBUM_ARTIST_CREDIT"
 	AlbumArtistsCredit = "ALBUMARTISTS_CREDIT" //tag: alts "ALBUM_ARTISTS_CREDIT"
+	AlbumArtistSort    = "ALBUMARTISTSORT"     //tag: alts "ALBUM_ARTIST_SORT"
 	Date               = "DATE"                //tag: alts "YEAR"
-	OriginalDate       = "ORIGINALDATE"        //tag: alts "ORIGINAL_YEAR"
+	OriginalDate       = "ORIGINALDATE"        //tag: alts "ORIGINAL_DATE"
+	OriginalYear       = "ORIGINALYEAR"        //tag: alts "ORIGINAL_YEAR"
 	MediaFormat        = "MEDIA"
 	Label              = "LABEL"
 	CatalogueNum       = "CATALOGNUMBER" //tag: alts "CATALOGNUM"
 	UPC                = "UPC"           //tag: alts "MCN"
+	Barcode            = "BARCODE"
+	ASIN               = "ASIN"
 	Compilation        = "COMPILATION"
+	ReleaseType        = "RELEASETYPE"    //tag: alts "MUSICBRAINZ_ALBUM_TYPE" "MUSICBRAINZ_ALBUMTYPE"
+	ReleaseStatus      = "RELEASESTATUS"  //tag: alts "MUSICBRAINZ_ALBUM_STATUS" "MUSICBRAINZ_ALBUMSTATUS"
+	ReleaseCountry     = "RELEASECOUNTRY" //tag: alts "MUSICBRAINZ_ALBUM_RELEASE_COUNTRY" "RELEASE_COUNTRY"
+	Script             = "SCRIPT"
+	Language           = "LANGUAGE"
 
 	MBReleaseID      = "MUSICBRAINZ_ALBUMID"
 	MBReleaseGroupID = "MUSICBRAINZ_RELEASEGROUPID"
@@ -39,13 +48,32 @@ const (
 	Artists       = "ARTISTS"
 	ArtistCredit  = "ARTIST_CREDIT"  //tag: alts "ARTISTCREDIT"
 	ArtistsCredit = "ARTISTS_CREDIT" //tag: alts "ARTISTSCREDIT"
+	ArtistSort    = "ARTISTSORT"     //tag: alts "ARTIST_SORT"
 	Genre         = "GENRE"
 	Genres        = "GENRES"
 	TrackNumber   = "TRACKNUMBER" //tag: alts "TRACK" "TRACKC"
 	DiscNumber    = "DISCNUMBER"
-
-	MBRecordingID = "MUSICBRAINZ_TRACKID"
-	MBArtistID    = "MUSICBRAINZ_ARTISTID"
+	ISRC          = "ISRC"
+
+	MBRecordingID    = "MUSICBRAINZ_TRACKID"
+	MBReleaseTrackID = "MUSICBRAINZ_RELEASETRACKID" //tag: alts "MUSICBRAINZ_RELEASE_TRACK_ID"
+	MBArtistID       = "MUSICBRAINZ_ARTISTID"
+
+	Work          = "WORK"
+	MovementName  = "MOVEMENTNAME"
+	Movement      = "MOVEMENT"      //tag: alts "MOVEMENTNUMBER"
+	MovementTotal = "MOVEMENTTOTAL" //tag: alts "MOVEMENTCOUNT"
+
+	Composer  = "COMPOSER"
+	Lyricist  = "LYRICIST"
+	Writer    = "WRITER"
+	Arranger  = "ARRANGER"
+	Conductor = "CONDUCTOR"
+	Producer  = "PRODUCER"
+	Engineer  = "ENGINEER"
+	Mixer     = "MIXER"
+	Remixer   = "REMIXER" //tag: alts "MIXARTIST"
+	Performer = "PERFORMER"
 
 	ReplayGainTrackGain = "REPLAYGAIN_TRACK_GAIN"
 	ReplayGainTrackPeak = "REPLAYGAIN_TRACK_PEAK"
@@ -119,6 +147,11 @@ func (t Tags) Get(key string) string {
 	return ""
 }
 
+func (t Tags) Has(key string) bool {
+	_, ok := t.t[NormKey(key)]
+	return ok
+}
+
 func (t Tags) Values(key string) []string {
 	return t.t[NormKey(key)]
 }
@@ -127,6 +160,20 @@ func Equal(a, b Tags) bool {
 	return maps.EqualFunc(a.t, b.t, slices.Equal)
 }
 
+// IsKnown reports whether the key is one of the tags above, or an alternative name for one. Keys with a description,
+// like "PERFORMER:piano", are known if the part before the ":" is.
+func IsKnown(k string) bool {
+	k = NormKey(k)
+	if _, ok := knownTags[k]; ok {
+		return true
+	}
+	if k, _, ok := strings.Cut(k, ":"); ok {
+		_, ok := knownTags[NormKey(k)]
+		return ok
+	}
+	return false
+}
+
 func NormKey(k string) string {
 	k = strings.ToUpper(k)
 	if nk, ok := alternatives[k]; ok {
@@ -36,13 +36,17 @@ func TestNormalise(t *testing.T) {
 		"trackc", "14",
 		"year", "1967",
 		"album artist credit", "Steve",
+		"musicbrainz album type", "album",
+		"musicbrainz release track id", "abc",
 	)
 
 	exp := map[string][]string{
-		"MEDIA":              {"CD"},
-		"TRACKNUMBER":        {"14"},
-		"DATE":               {"1967"},
-		"ALBUMARTIST_CREDIT": {"Steve"},
+		"MEDIA":                      {"CD"},
+		"TRACKNUMBER":                {"14"},
+		"DATE":                       {"1967"},
+		"ALBUMARTIST_CREDIT":         {"Steve"},
+		"RELEASETYPE":                {"album"},
+		"MUSICBRAINZ_RELEASETRACKID": {"abc"},
 	}
 
 	require.Equal(t, exp, maps.Collect(got.Iter()))
@@ -71,11 +75,17 @@ func TestExtendedTags(t *testing.T) {
 				f.Set(Artist, "1. steely dan")            // standard
 				f.Set(AlbumArtist, "2. steely dan")       // extended
 				f.Set(AlbumArtistCredit, "3. steely dan") // non standard
+				f.Set(ISRC, "USMC17310009")
+				f.Set(ReleaseType, "album", "compilation")
+				f.Set(MBReleaseTrackID, "4. steely dan")
 			})
 			withf(t, p, func(f *Tags) {
 				assert.Equal(t, "1. steely dan", f.Get(Artist))
 				assert.Equal(t, "2. steely dan", f.Get(AlbumArtist))
 				assert.Equal(t, "3. steely dan", f.Get(AlbumArtistCredit))
+				assert.Equal(t, "USMC17310009", f.Get(ISRC))
+				assert.Equal(t, []string{"album", "compilation"}, f.Values(ReleaseType))
+				assert.Equal(t, "4. steely dan", f.Get(MBReleaseTrackID))
 			})
 		})
 	}
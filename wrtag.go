@@ -7,11 +7,14 @@ package wrtag
 import (
 	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"log/slog"
+	"maps"
+	"math"
 	"net/http"
 	"os"
 	"path"
@@ -19,20 +22,27 @@ import (
 	"slices"
 	"strings"
 	"syscall"
+	texttemplate "text/template"
 	"time"
 
 	"github.com/KarpelesLab/reflink"
-	"github.com/araddon/dateparse"
 	"github.com/argusdusty/treelock"
 	"go.senan.xyz/natcmp"
+	"go.senan.xyz/wrtag/acoustid"
 	"go.senan.xyz/wrtag/addon"
 	"go.senan.xyz/wrtag/coverparse"
+	"go.senan.xyz/wrtag/cuesplit"
+	"go.senan.xyz/wrtag/discid"
 	"go.senan.xyz/wrtag/fileutil"
+	"go.senan.xyz/wrtag/ignore"
+	"go.senan.xyz/wrtag/imageutil"
 	"go.senan.xyz/wrtag/musicbrainz"
+	"go.senan.xyz/wrtag/nameparse"
 	"go.senan.xyz/wrtag/originfile"
 	"go.senan.xyz/wrtag/pathformat"
 	"go.senan.xyz/wrtag/tagmap"
 	"go.senan.xyz/wrtag/tags"
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -53,6 +63,9 @@ var (
 
 	// ErrSelfCopy is returned when attempting to copy a file to itself.
 	ErrSelfCopy = errors.New("can't copy self to self")
+
+	// ErrSkipped is returned when the source directory's overrides file asks for it to be skipped.
+	ErrSkipped = errors.New("skipped by overrides file")
 )
 
 // IsNonFatalError determines whether an error is non-fatal during processing.
@@ -92,7 +105,46 @@ type SearchResult struct {
 	Diff []tagmap.Diff
 
 	// OriginFile contains information from any gazelle-origin file found in the source directory
+	//
+	// Deprecated: Use Sidecars, which has it along with the other formats.
 	OriginFile *originfile.OriginFile
+
+	// Sidecars are the origin files and store metadata found in the source directory, such as gazelle-origin files
+	Sidecars []originfile.Sidecar
+
+	// Overrides are the settings from the source directory's overrides file, if it has one
+	Overrides *Overrides
+
+	// Inferred are the values taken from file and directory names, since they weren't in the tags
+	Inferred []InferredValue
+}
+
+// UnmarshalJSON reads search results stored before there were sidecars too, when a gazelle-origin file was only in
+// OriginFile, so that jobs saved by older versions of wrtagweb still show it.
+func (sr *SearchResult) UnmarshalJSON(data []byte) error {
+	type searchResult SearchResult
+	if err := json.Unmarshal(data, (*searchResult)(sr)); err != nil {
+		return err
+	}
+	if sr.OriginFile != nil && len(sr.Sidecars) == 0 {
+		sr.Sidecars = []originfile.Sidecar{{Format: "gazelle-origin", Hints: *sr.OriginFile.Hints()}}
+	}
+	return nil
+}
+
+// InferredValue is a value that wasn't in the tags, so was taken from the name of a file or directory.
+type InferredValue struct {
+	// Field is the field of the pattern, such as "album", with the track number if it's from a file, like "track 2 title"
+	Field string
+
+	// Value is what was found in the name
+	Value string
+
+	// Name is the file or directory name it was found in
+	Name string
+
+	// Pattern is the pattern that matched the name
+	Pattern string
 }
 
 // ImportCondition defines the conditions under which a release will be imported.
@@ -117,29 +169,205 @@ type Config struct {
 	// CoverArtArchiveClient is used to retrieve cover art
 	CoverArtArchiveClient musicbrainz.CAAClient
 
+	// AcoustIDClient is used to find releases from audio fingerprints when a directory has no usable tags
+	AcoustIDClient acoustid.Client
+
 	// PathFormat defines the directory structure for organising music files
 	PathFormat pathformat.Format
 
 	// TagWeights defines the relative importance of different tags when calculating match scores
 	TagWeights tagmap.TagWeights
 
+	// Relationships specifies which tags to write from MusicBrainz artist relationships, such as composers and performers
+	Relationships tagmap.Relationships
+
+	// GenrePolicy filters, renames, and ranks the genres written to tags
+	GenrePolicy tagmap.GenrePolicy
+
+	// FeatMatch compares tracks without regard to whether featured artists are in the artist or the title
+	FeatMatch bool
+
+	// FeatStyle decides whether featured artists are written in the artist, the title, or both
+	FeatStyle tagmap.FeatStyle
+
+	// TagMerge decides how the existing value of each tag is combined with the new one. By default the new one is used
+	TagMerge tagmap.MergeStrategies
+
+	// TagRetention decides which existing tags are kept when retagging. By default they all are
+	TagRetention tagmap.RetentionPolicy
+
+	// TagRules set extra tags from templates, on top of the ones written from the release
+	TagRules []tagmap.TagRule
+
+	// Locales is a preference list of locales, such as "ja" or "en", used to pick the names of artists from their aliases
+	Locales []string
+
+	// LocaleScript is a script, such as "Latn", used to take titles from a pseudo-release in that script when the
+	// release itself isn't written in it
+	LocaleScript string
+
+	// Classical enables work and movement tags for classical music, following recordings to the works they perform
+	Classical bool
+
 	// KeepFiles specifies files that should be preserved during processing
 	KeepFiles map[string]struct{}
 
 	// Addons are plugins that can perform additional processing after the main import
 	Addons []addon.Addon
 
-	// UpgradeCover specifies whether to attempt to replace existing covers with better versions
+	// UpgradeCover specifies whether to attempt to replace existing covers with better versions. Covers embedded in the
+	// tracks count as existing, so without it they aren't replaced with downloaded ones, even if they're small
 	UpgradeCover bool
+
+	// CoverPolicy decides how covers are resized, converted, and named. By default they are kept as they are, named cover
+	CoverPolicy CoverPolicy
+
+	// ScanTypes are the types of extra Cover Art Archive images to download, such as "Back", "Booklet", or "Medium"
+	ScanTypes []string
+
+	// ScansDir is the directory in the release directory that extra images are downloaded to. By default it's "scans"
+	ScansDir string
+
+	// DirPatterns and FilePatterns find details in the names of directories and files, for releases without tags.
+	// They are tried before the built-in patterns
+	DirPatterns, FilePatterns []nameparse.Pattern
+
+	// ArchiveAction decides what happens to a zip or tar archive once the release in it is imported
+	ArchiveAction ArchiveAction
+
+	// ArchiveDoneDir is the directory archives are moved to with ArchiveMove
+	ArchiveDoneDir string
+
+	// CueSplitTool is the command used to split a single file CD image into tracks, using the CUE sheet next to it
+	CueSplitTool cuesplit.Tool
+
+	// Ignore are patterns for paths to leave out when reading releases and syncing, on top of any .wrtagignore files
+	Ignore []ignore.Pattern
+
+	// IgnoreOverridesConfirm leaves releases to be confirmed by hand even if their OverridesFile confirms them
+	IgnoreOverridesConfirm bool
+}
+
+// ArchiveAction decides what happens to an archive once the release in it is imported.
+type ArchiveAction uint8
+
+const (
+	// ArchiveKeep leaves the archive where it is
+	ArchiveKeep ArchiveAction = iota
+
+	// ArchiveDelete deletes the archive
+	ArchiveDelete
+
+	// ArchiveMove moves the archive to the ArchiveDoneDir
+	ArchiveMove
+)
+
+var archiveActionNames = []string{
+	ArchiveKeep:   "keep",
+	ArchiveDelete: "delete",
+	ArchiveMove:   "move",
+}
+
+func ParseArchiveAction(s string) (ArchiveAction, error) {
+	i := slices.Index(archiveActionNames, strings.ToLower(strings.TrimSpace(s)))
+	if i < 0 {
+		return 0, fmt.Errorf("unknown archive action %q. expected one of %s", s, strings.Join(archiveActionNames, ", "))
+	}
+	return ArchiveAction(i), nil
+}
+
+func (a ArchiveAction) String() string {
+	if int(a) < len(archiveActionNames) {
+		return archiveActionNames[a]
+	}
+	return ""
+}
+
+// StagingDirPrefix starts the names of the temporary directories made in the library root for extracted archives and
+// split CD images. They're only there while a release is being imported, so they shouldn't be synced.
+const StagingDirPrefix = ".wrtag-"
+
+// OverridesFile is the name of an optional file in a source directory with settings for just that directory.
+const OverridesFile = ".wrtag.yaml"
+
+// Overrides are settings for a single directory, read from its OverridesFile. The file is kept with the release
+// when it's imported, so that it applies to later syncs too.
+type Overrides struct {
+	// MBID is the MusicBrainz release to use, like the -mbid flag
+	MBID string `yaml:"mbid" json:",omitempty"`
+
+	// Confirm imports the release whatever its score, unless the Config ignores it
+	Confirm bool `yaml:"confirm" json:",omitempty"`
+
+	// Skip leaves the directory alone
+	Skip bool `yaml:"skip" json:",omitempty"`
+
+	// Operation is "copy" or "move", and replaces the operation when syncing
+	Operation string `yaml:"operation" json:",omitempty"`
+
+	// KeepFiles are kept along with the ones from the config
+	KeepFiles []string `yaml:"keep-files" json:",omitempty"`
+
+	// Tags are written to every track, replacing the values wrtag would write. A tag with no values is cleared
+	Tags map[string]OverrideValues `yaml:"tags" json:",omitempty"`
+
+	// PathFormat replaces the path format for this release. It's relative to the root of the configured one
+	PathFormat string `yaml:"path-format" json:",omitempty"`
+}
+
+// OverrideValues are the values of an overridden tag, written in the file as either a single value or a list.
+type OverrideValues []string
+
+func (v *OverrideValues) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*v = OverrideValues{node.Value}
+		return nil
+	}
+	return node.Decode((*[]string)(v))
+}
+
+// ReadOverrides reads the OverridesFile in dir. It returns nil if there isn't one.
+func ReadOverrides(dir string) (*Overrides, error) {
+	data, err := os.ReadFile(filepath.Join(dir, OverridesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+
+	var o Overrides
+	if err := yaml.Unmarshal(data, &o); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", OverridesFile, err)
+	}
+	switch o.Operation {
+	case "", "copy", "move":
+	default:
+		return nil, fmt.Errorf("unknown operation %q", o.Operation)
+	}
+	// the file travels with the release, so it can't reach outside of the directory or the library
+	if o.PathFormat != "" && !filepath.IsLocal(o.PathFormat) {
+		return nil, fmt.Errorf("path format %q is not relative to the library", o.PathFormat)
+	}
+	for _, kf := range o.KeepFiles {
+		if !filepath.IsLocal(kf) {
+			return nil, fmt.Errorf("keep file %q is not in the directory", kf)
+		}
+	}
+	return &o, nil
 }
 
 // ProcessDir processes a music directory by looking up metadata on MusicBrainz and
 // either moving, copying, or reflinking the files to a new location with proper tags.
 // It returns a SearchResult containing information about the match and operation.
 //
-// The srcDir must be an absolute path.
+// The srcDir must be an absolute path. It can also be a zip or tar archive, which is extracted next to the library
+// and imported from there. The archive is then kept, deleted, or moved according to cfg.ArchiveAction.
 // The cond parameter determines the conditions under which the import will proceed.
 // The useMBID parameter can be used to force a specific MusicBrainz release ID.
+// Settings from an OverridesFile in srcDir are used too, though useMBID takes precedence over its MBID.
+// A directory with a single file CD image and a CUE sheet is matched using the sheet, and split into tracks with
+// cfg.CueSplitTool once it's being imported.
 func ProcessDir(
 	ctx context.Context, cfg *Config,
 	op FileSystemOperation, srcDir string, cond ImportCondition, useMBID string,
@@ -153,7 +381,71 @@ func ProcessDir(
 	}
 	srcDir = filepath.Clean(srcDir)
 
-	cover, pathTags, err := ReadReleaseDir(srcDir)
+	// the name of the release directory, or of the archive it was in
+	nameDir := srcDir
+
+	var archivePath string
+	if fileutil.IsArchive(srcDir) {
+		if cfg.ArchiveAction == ArchiveMove && cfg.ArchiveDoneDir == "" {
+			return nil, fmt.Errorf("no done dir for archives provided")
+		}
+
+		archivePath = srcDir
+		var tmpDir string
+		var err error
+		tmpDir, srcDir, err = extractArchive(cfg, op, archivePath)
+		if tmpDir != "" {
+			defer os.RemoveAll(tmpDir)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("extract archive: %w", err)
+		}
+
+		nameDir = strings.TrimSuffix(archivePath, fileutil.ArchiveExt(archivePath))
+		if srcDir != tmpDir {
+			nameDir = srcDir
+		}
+
+		// the extracted files are only temporary, so there's no need to copy them
+		if op.CanModifyDest() {
+			op = NewMove(false)
+		}
+	}
+
+	overrides, err := ReadOverrides(srcDir)
+	if err != nil {
+		return nil, fmt.Errorf("read overrides: %w", err)
+	}
+
+	pathFormat := &cfg.PathFormat
+	keepFiles := maps.Clone(cfg.KeepFiles)
+	if overrides != nil {
+		slog.DebugContext(ctx, "using overrides", "dir", srcDir, "overrides", overrides)
+
+		if overrides.Skip {
+			return nil, ErrSkipped
+		}
+		useMBID = cmp.Or(useMBID, overrides.MBID)
+		if overrides.Confirm && !cfg.IgnoreOverridesConfirm {
+			cond = Confirm
+		}
+		if overrides.PathFormat != "" {
+			// formats are in the library, so the file can't send releases anywhere else
+			pathFormat = &pathformat.Format{Classical: cfg.Classical}
+			if err := pathFormat.Parse(cfg.PathFormat.Root() + string(filepath.Separator) + overrides.PathFormat); err != nil {
+				return nil, fmt.Errorf("parse override path format: %w", err)
+			}
+		}
+		if keepFiles == nil {
+			keepFiles = map[string]struct{}{}
+		}
+		keepFiles[OverridesFile] = struct{}{}
+		for _, kf := range overrides.KeepFiles {
+			keepFiles[kf] = struct{}{}
+		}
+	}
+
+	cover, pathTags, err := ReadRelease(srcDir, cfg.Ignore)
 	if err != nil {
 		return nil, fmt.Errorf("read dir: %w", err)
 	}
@@ -161,7 +453,26 @@ func ProcessDir(
 		return nil, ErrNoTracks
 	}
 
+	// a single file CD image is matched using the tracks in its CUE sheet, and only split once it's being imported
+	coverTracks := pathTags
+	var image *cuesplit.Image
+	if len(pathTags) == 1 {
+		image, err = cuesplit.Find(srcDir)
+		if err != nil {
+			return nil, fmt.Errorf("find cd image: %w", err)
+		}
+	}
+	if image != nil && image.Path == pathTags[0].Path {
+		pathTags, err = imageTracks(image, srcDir)
+		if err != nil {
+			return nil, fmt.Errorf("read cd image tracks: %w", err)
+		}
+	} else {
+		image = nil
+	}
+
 	searchTags := pathTags[0].Tags
+	namedTags, inferred := inferFromNames(cfg, nameDir, pathTags)
 
 	var mbid = searchTags.Get(tags.MBReleaseID)
 	if useMBID != "" {
@@ -174,7 +485,7 @@ func ProcessDir(
 		MBReleaseGroupID: searchTags.Get(tags.MBReleaseGroupID),
 		Release:          searchTags.Get(tags.Album),
 		Artist:           cmp.Or(searchTags.Get(tags.AlbumArtist), searchTags.Get(tags.Artist)),
-		Date:             parseAnyTime(searchTags.Get(tags.Date)),
+		Date:             parseDate(searchTags.Get(tags.Date)),
 		Format:           searchTags.Get(tags.MediaFormat),
 		Label:            searchTags.Get(tags.Label),
 		CatalogueNum:     searchTags.Get(tags.CatalogueNum),
@@ -182,52 +493,132 @@ func ProcessDir(
 		NumTracks:        len(pathTags),
 	}
 
-	// parse https://github.com/x1ppy/gazelle-origin files, if one exists
-	originFile, err := originfile.Find(srcDir)
+	// fingerprints are better than names, so only look at the tags to decide whether to use them
+	untagged := !hasUsableTags(query)
+	if len(inferred) > 0 {
+		inferredTags := namedTags[0].inferred
+		query.Release = cmp.Or(query.Release, inferredTags[tags.Album])
+		query.Artist = cmp.Or(query.Artist, inferredTags[tags.AlbumArtist])
+		if query.Date.IsZero() {
+			query.Date = parseDate(inferredTags[tags.Date])
+		}
+		query.Format = cmp.Or(query.Format, inferredTags[tags.MediaFormat])
+		query.Label = cmp.Or(query.Label, inferredTags[tags.Label])
+		query.CatalogueNum = cmp.Or(query.CatalogueNum, inferredTags[tags.CatalogueNum])
+	}
+
+	// parse sidecars like https://github.com/x1ppy/gazelle-origin files, if there are any
+	sidecars, err := originfile.FindSidecars(srcDir)
 	if err != nil {
-		return nil, fmt.Errorf("find origin file: %w", err)
+		return nil, fmt.Errorf("find sidecars: %w", err)
+	}
+	var originFile *originfile.OriginFile
+	if i := slices.IndexFunc(sidecars, func(s originfile.Sidecar) bool { return s.Format == "gazelle-origin" }); i >= 0 {
+		originFile, err = originfile.Parse(sidecars[i].Path)
+		if err != nil {
+			return nil, fmt.Errorf("parse origin file: %w", err)
+		}
 	}
 
 	if mbid == "" {
-		if err := extendQueryWithOriginFile(&query, originFile); err != nil {
-			return nil, fmt.Errorf("use origin file: %w", err)
+		// a release id from a sidecar is searched for, but still has to score well enough to be imported
+		extendQueryWithSidecars(&query, sidecars)
+	}
+
+	var release *musicbrainz.Release
+	if mbid == "" {
+		release, err = searchDiscID(ctx, cfg, overrides, srcDir, pathTags)
+		if err != nil {
+			// like fingerprints, a disc id is only a better way to find the release
+			slog.WarnContext(ctx, "search disc id", "err", err)
+			release = nil
+		}
+	}
+	if release == nil && mbid == "" && untagged && cfg.AcoustIDClient.Enabled() {
+		release, err = searchFingerprints(ctx, cfg, overrides, pathTags)
+		if err != nil {
+			// fingerprints are only a better way to find the release, so fall back to searching with the query
+			slog.WarnContext(ctx, "search fingerprints", "err", err)
+			release = nil
+		}
+	}
+	// an untagged release found with only what's in its names could be any release that's named the same
+	var namesOnly bool
+	if release == nil {
+		namesOnly = untagged && len(inferred) > 0 && query.MBReleaseID == ""
+		release, err = cfg.MusicBrainzClient.SearchRelease(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("search musicbrainz: %w", err)
 		}
 	}
 
-	release, err := cfg.MusicBrainzClient.SearchRelease(ctx, query)
-	if err != nil {
-		return nil, fmt.Errorf("search musicbrainz: %w", err)
+	if err := localiseRelease(ctx, cfg, release); err != nil {
+		return nil, fmt.Errorf("localise release: %w", err)
 	}
 
 	releaseTracks := musicbrainz.FlatTracks(release.Media)
 
-	score, diff := tagmap.DiffRelease(cfg.TagWeights, release, releaseTracks, pathTags)
+	tagOpts := tagOptions(cfg)
+	score, diff := tagmap.DiffRelease(cfg.TagWeights, tagOpts, release, releaseTracks, namedTags, writtenTags(cfg, overrides, release, pathTags))
 
 	if len(pathTags) != len(releaseTracks) {
-		return &SearchResult{release, query, 0, "", diff, originFile}, fmt.Errorf("%w: %d remote / %d local", ErrTrackCountMismatch, len(releaseTracks), len(pathTags))
+		return &SearchResult{release, query, 0, "", diff, originFile, sidecars, overrides, inferred}, fmt.Errorf("%w: %d remote / %d local", ErrTrackCountMismatch, len(releaseTracks), len(pathTags))
+	}
+
+	labelInfo := musicbrainz.AnyLabelInfo(release)
+	genres := cfg.GenrePolicy.Genres(release)
+
+	if cfg.TagRetention.Mode != tagmap.RetainAll {
+		if removed := removedTags(cfg, release, labelInfo, genres, releaseTracks, pathTags, tagOpts); len(removed) > 0 {
+			diff = append(diff, tagmap.DiffRemoved(removed))
+		}
 	}
 
 	var shouldImport bool
 	switch cond {
 	case HighScoreOrMBID:
-		shouldImport = score >= minScore || mbid != ""
+		shouldImport = (score >= minScore && !namesOnly) || mbid != ""
 	case HighScore:
-		shouldImport = score >= minScore
+		shouldImport = score >= minScore && !namesOnly
 	case Confirm:
 		shouldImport = true
 	}
+	if !shouldImport && namesOnly && score >= minScore {
+		slog.InfoContext(ctx, "only matched names, confirm to import", "score", score)
+	}
 
 	if !shouldImport {
-		return &SearchResult{release, query, score, "", diff, originFile}, ErrScoreTooLow
+		return &SearchResult{release, query, score, "", diff, originFile, sidecars, overrides, inferred}, ErrScoreTooLow
+	}
+
+	if cfg.Classical {
+		if err := cfg.MusicBrainzClient.FetchParentWorks(ctx, release); err != nil {
+			return nil, fmt.Errorf("fetch parent works: %w", err)
+		}
 	}
 
-	destDir, err := DestDir(&cfg.PathFormat, release)
+	destDir, err := DestDir(pathFormat, release)
 	if err != nil {
 		return nil, fmt.Errorf("gen dest dir: %w", err)
 	}
+	// the format's data, or an overrides file's format, could still render a path that leads out
+	if !inRoot(cfg.PathFormat.Root(), destDir) {
+		return nil, fmt.Errorf("dest dir %q is not in the library", destDir)
+	}
 
-	labelInfo := musicbrainz.AnyLabelInfo(release)
-	genres := musicbrainz.AnyGenres(release)
+	// calculate new paths
+	destPaths := make([]string, 0, len(pathTags))
+	for i, pt := range pathTags {
+		destPath, err := pathFormat.Execute(release, i, strings.ToLower(filepath.Ext(pt.Path)))
+		if err != nil {
+			return nil, fmt.Errorf("create path: %w", err)
+		}
+		if !inRoot(cfg.PathFormat.Root(), destPath) {
+			return nil, fmt.Errorf("dest path %q is not in the library", destPath)
+		}
+
+		destPaths = append(destPaths, destPath)
+	}
 
 	// lock both source and destination directories
 	unlock := lockPaths(
@@ -235,28 +626,69 @@ func ProcessDir(
 		destDir,
 	)
 
-	// calculate new paths
-	destPaths := make([]string, 0, len(pathTags))
-	for i, pt := range pathTags {
-		destPath, err := cfg.PathFormat.Execute(release, i, strings.ToLower(filepath.Ext(pt.Path)))
+	// the split tracks are moved into place whatever the operation
+	trackOp := op
+	if image != nil && op.CanModifyDest() {
+		splitDir, splitPaths, err := splitImage(ctx, cfg, image)
+		if splitDir != "" {
+			defer os.RemoveAll(splitDir)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("create path: %w", err)
+			return nil, fmt.Errorf("split image: %w", err)
 		}
-
-		destPaths = append(destPaths, destPath)
+		for i := range pathTags {
+			pathTags[i].Path = splitPaths[i]
+		}
+		trackOp = NewMove(false)
 	}
 
 	dc := NewDirContext()
 
+	// use a cover embedded in the tracks if there isn't a file, before they're moved
+	coverOp := op
+	if cover.Path == "" && op.CanModifyDest() {
+		cover, err = extractEmbeddedCover(coverTracks)
+		if err != nil {
+			return nil, fmt.Errorf("extract embedded cover: %w", err)
+		}
+		if cover.Path != "" {
+			defer os.Remove(cover.Path)
+			// it's a temporary file like a downloaded cover, and may not be on the library's filesystem
+			coverOp = Move{}
+		}
+	}
+
 	// move/copy and tag
 	for i := range len(pathTags) {
 		pt, rt, destPath := pathTags[i], releaseTracks[i], destPaths[i]
 
-		if err := op.ProcessPath(dc, pt.Path, destPath); err != nil {
+		if err := trackOp.ProcessPath(dc, pt.Path, destPath); err != nil {
 			return nil, fmt.Errorf("process path %q: %w", filepath.Base(pt.Path), err)
 		}
 
-		destTags := tagmap.ReleaseTags(release, labelInfo, genres, i, &rt)
+		destTags := tagmap.ReleaseTags(release, labelInfo, genres, i, &rt, tagOpts)
+		tagmap.ClearRelationRoles(pt.Tags, &destTags, tagOpts.Relationships)
+		if err := tagmap.ApplyTagRules(&destTags, cfg.TagRules, release, i, strings.ToLower(filepath.Ext(pt.Path)), cfg.Classical); err != nil {
+			return nil, fmt.Errorf("apply tag rules: %w", err)
+		}
+
+		cfg.TagMerge.Merge(pt.Tags, &destTags)
+
+		writeTags := tags.WriteTags // not replacing by default since some plugins use other tags
+		if cfg.TagRetention.Mode != tagmap.RetainAll {
+			if lvl, slog := slog.LevelDebug, slog.Default(); slog.Enabled(ctx, lvl) {
+				for _, k := range cfg.TagRetention.Removed(pt.Tags, destTags) {
+					slog.Log(ctx, lvl, "tag removed", "file", filepath.Base(pt.Path), "key", k)
+				}
+			}
+			destTags = cfg.TagRetention.Apply(pt.Tags, destTags)
+			writeTags = tags.ReplaceTags
+		}
+		if overrides != nil {
+			for k, vs := range overrides.Tags {
+				destTags.Set(k, vs...)
+			}
+		}
 
 		if lvl, slog := slog.LevelDebug, slog.Default(); slog.Enabled(ctx, lvl) {
 			logTagChanges(ctx, pt.Path, lvl, pt.Tags, destTags)
@@ -270,14 +702,17 @@ func ProcessDir(
 			continue
 		}
 
-		if err := tags.WriteTags(destPath, destTags); err != nil { // not replacing here since some plugins use other tags
+		if err := writeTags(destPath, destTags); err != nil {
 			return nil, fmt.Errorf("write tag file: %w", err)
 		}
 	}
 
-	if err := processCover(ctx, cfg, op, dc, release, destDir, cover); err != nil {
+	if err := processCover(ctx, cfg, coverOp, dc, release, destDir, cover); err != nil {
 		return nil, fmt.Errorf("process cover: %w", err)
 	}
+	if err := processScans(ctx, cfg, op, dc, release, destDir); err != nil {
+		return nil, fmt.Errorf("process scans: %w", err)
+	}
 
 	// process addons with new files
 	if op.CanModifyDest() {
@@ -288,25 +723,195 @@ func ProcessDir(
 		}
 	}
 
-	for kf := range cfg.KeepFiles {
+	for kf := range keepFiles {
 		if err := op.ProcessPath(dc, filepath.Join(srcDir, kf), filepath.Join(destDir, kf)); err != nil && !errors.Is(err, os.ErrNotExist) {
 			return nil, fmt.Errorf("process keep file %q: %w", kf, err)
 		}
 	}
 
+	// ignored files aren't part of the release, so they aren't extra either
+	destIgnored, err := ignoredPaths(destDir, cfg.Ignore)
+	if err != nil {
+		return nil, fmt.Errorf("find ignored dest paths: %w", err)
+	}
+	for _, p := range destIgnored {
+		dc.knownDestPaths[p] = struct{}{}
+	}
+
 	if err := trimDestDir(dc, destDir, op.CanModifyDest()); err != nil {
 		return nil, fmt.Errorf("trim: %w", err)
 	}
 
+	// the image was moved as its tracks
+	if _, ok := op.(Move); ok && image != nil && op.CanModifyDest() {
+		if err := os.Remove(image.Path); err != nil {
+			return nil, fmt.Errorf("remove image: %w", err)
+		}
+		slog.DebugContext(ctx, "removed path", "path", image.Path)
+	}
+
 	unlock()
 
-	if srcDir != destDir {
-		if err := op.PostSource(dc, cfg.PathFormat.Root(), srcDir); err != nil {
+	// an extracted archive is removed whatever is left in it
+	if srcDir != destDir && archivePath == "" {
+		srcIgnored, err := ignoredPaths(srcDir, cfg.Ignore)
+		if err != nil {
+			return nil, fmt.Errorf("find ignored source paths: %w", err)
+		}
+		if len(srcIgnored) > 0 {
+			// the ignored files weren't moved, so they'd be lost along with the dir
+			slog.InfoContext(ctx, "keeping source dir with ignored files", "dir", srcDir)
+		} else if err := op.PostSource(dc, cfg.PathFormat.Root(), srcDir); err != nil {
 			return nil, fmt.Errorf("clean: %w", err)
 		}
 	}
 
-	return &SearchResult{release, query, score, destDir, diff, originFile}, nil
+	if archivePath != "" && op.CanModifyDest() {
+		if err := finishArchive(cfg, archivePath); err != nil {
+			return nil, fmt.Errorf("finish archive: %w", err)
+		}
+	}
+
+	return &SearchResult{release, query, score, destDir, diff, originFile, sidecars, overrides, inferred}, nil
+}
+
+// An archive can extract to maxArchiveRatio times its size, or minMaxArchiveBytes if that's more.
+const (
+	maxArchiveRatio    = 10
+	minMaxArchiveBytes = 1 << 30
+)
+
+// extractArchive extracts an archive to a temporary directory in the library root, so that the files can be moved
+// into place rather than copied. Dry runs extract to the system temporary directory instead, so that they leave the
+// library alone. It returns the directory, and the directory of the release in it, which is a subdirectory if that's
+// all the archive has.
+func extractArchive(cfg *Config, op FileSystemOperation, archivePath string) (tmpDir, srcDir string, err error) {
+	if op.CanModifyDest() {
+		tmpDir, err = makeStagingDir(cfg, "archive")
+	} else {
+		tmpDir, err = os.MkdirTemp("", StagingDirPrefix+"archive-*")
+	}
+	if err != nil {
+		return "", "", err
+	}
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return tmpDir, "", fmt.Errorf("stat archive: %w", err)
+	}
+	// music hardly compresses, so an archive that extracts to much more than its size is likely a zip bomb
+	maxBytes := max(info.Size()*maxArchiveRatio, minMaxArchiveBytes)
+	if err := fileutil.ExtractArchive(archivePath, tmpDir, maxBytes); err != nil {
+		return tmpDir, "", err
+	}
+	slog.Debug("extracted archive", "archive", archivePath, "to", tmpDir)
+
+	srcDir = tmpDir
+	if entries, err := os.ReadDir(tmpDir); err == nil && len(entries) == 1 && entries[0].IsDir() {
+		srcDir = filepath.Join(tmpDir, entries[0].Name())
+	}
+	return tmpDir, srcDir, nil
+}
+
+// imageTracks returns the tracks of a CD image from its CUE sheet, with the paths they would be split to in dir, so
+// that the release can be found without splitting it.
+func imageTracks(image *cuesplit.Image, dir string) ([]PathTags, error) {
+	paths, trackTags, err := cuesplit.Tracks(image, dir)
+	if err != nil {
+		return nil, err
+	}
+	var pathTags []PathTags
+	for i := range paths {
+		pathTags = append(pathTags, PathTags{Path: paths[i], Tags: trackTags[i]})
+	}
+	return pathTags, nil
+}
+
+// splitImage splits a CD image into tracks in a temporary directory in the library root. The tracks are in the same
+// order as imageTracks.
+func splitImage(ctx context.Context, cfg *Config, image *cuesplit.Image) (splitDir string, paths []string, err error) {
+	splitDir, err = makeStagingDir(cfg, "split")
+	if err != nil {
+		return "", nil, err
+	}
+	paths, err = cuesplit.Split(ctx, cfg.CueSplitTool, image, splitDir)
+	if err != nil {
+		return splitDir, nil, err
+	}
+	slog.DebugContext(ctx, "split image", "image", image.Path, "cue", image.CuePath, "tracks", len(paths))
+	return splitDir, paths, nil
+}
+
+// makeStagingDir makes a temporary directory in the library root, so that files made there can be moved into the
+// library rather than copied. Its name starts with StagingDirPrefix and kind.
+func makeStagingDir(cfg *Config, kind string) (string, error) {
+	root := cfg.PathFormat.Root()
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return "", fmt.Errorf("create root: %w", err)
+	}
+	dir, err := os.MkdirTemp(root, StagingDirPrefix+kind+"-*")
+	if err != nil {
+		return "", fmt.Errorf("make temp dir: %w", err)
+	}
+	return dir, nil
+}
+
+// staleStagingAge is how old a staging directory has to be before it's thought to be left over from an import that
+// crashed or was killed, rather than one that's still going.
+const staleStagingAge = 24 * time.Hour
+
+// RemoveStaleStagingDirs removes the staging directories in the library root that were left behind by imports that
+// didn't finish.
+func RemoveStaleStagingDirs(cfg *Config) error {
+	root := cfg.PathFormat.Root()
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read root: %w", err)
+	}
+
+	var removeErrs []error
+	for _, entry := range entries {
+		if !entry.IsDir() || !strings.HasPrefix(entry.Name(), StagingDirPrefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			removeErrs = append(removeErrs, err)
+			continue
+		}
+		if time.Since(info.ModTime()) < staleStagingAge {
+			continue
+		}
+		path := filepath.Join(root, entry.Name())
+		if err := os.RemoveAll(path); err != nil {
+			removeErrs = append(removeErrs, err)
+			continue
+		}
+		slog.Info("removed stale staging dir", "path", path)
+	}
+	if err := errors.Join(removeErrs...); err != nil {
+		return fmt.Errorf("remove stale staging dirs: %w", err)
+	}
+	return nil
+}
+
+// finishArchive keeps, deletes, or moves an archive after the release in it was imported.
+func finishArchive(cfg *Config, archivePath string) error {
+	switch cfg.ArchiveAction {
+	case ArchiveDelete:
+		if err := os.Remove(archivePath); err != nil {
+			return fmt.Errorf("delete: %w", err)
+		}
+		slog.Debug("removed path", "path", archivePath)
+	case ArchiveMove:
+		dest := filepath.Join(cfg.ArchiveDoneDir, filepath.Base(archivePath))
+		if err := NewMove(false).ProcessPath(NewDirContext(), archivePath, dest); err != nil {
+			return fmt.Errorf("move to done dir: %w", err)
+		}
+	}
+	return nil
 }
 
 // PathTags associates a file path with its tags.
@@ -322,29 +927,51 @@ type PathTags struct {
 // It returns the path to the cover image (if found) and a slice of PathTags for each audio file.
 // Files are sorted by disc number, directory, track number, and finally path.
 func ReadReleaseDir(dirPath string) (string, []PathTags, error) {
+	cover, pathTags, err := ReadRelease(dirPath, nil)
+	return cover.Path, pathTags, err
+}
+
+// ReadRelease is like ReadReleaseDir, but it returns the best cover image with its size and format (with an empty
+// path if none was found), and leaves out files matching the ignore patterns or the patterns in .wrtagignore files.
+func ReadRelease(dirPath string, ignorePatterns []ignore.Pattern) (coverparse.Cover, []PathTags, error) {
+	ignored, err := ignore.New(dirPath, ignorePatterns)
+	if err != nil {
+		return coverparse.Cover{}, nil, fmt.Errorf("read ignore files: %w", err)
+	}
+
 	mainPaths, err := fileutil.GlobDir(dirPath, "*")
 	if err != nil {
-		return "", nil, fmt.Errorf("glob dir: %w", err)
+		return coverparse.Cover{}, nil, fmt.Errorf("glob dir: %w", err)
 	}
 	discPaths, err := fileutil.GlobDir(dirPath, "*/*") // recurse once for any disc1/ disc2/ dirs
 	if err != nil {
-		return "", nil, fmt.Errorf("glob dir for discs: %w", err)
+		return coverparse.Cover{}, nil, fmt.Errorf("glob dir for discs: %w", err)
 	}
 
-	var cover string
+	var cover coverparse.Cover
 	var pathTags []PathTags
 
 	paths := append(mainPaths, discPaths...)
 	for _, path := range paths {
+		if dir := filepath.Dir(path); ignored.Ignored(path, false) || (dir != filepath.Clean(dirPath) && ignored.Ignored(dir, true)) {
+			continue
+		}
+
 		if coverparse.IsCover(path) {
-			coverparse.BestBetween(&cover, path)
+			c, err := coverparse.Stat(path)
+			if err != nil {
+				// a broken cover shouldn't stop the tracks being read, there may be others
+				slog.Warn("read cover", "path", path, "err", err)
+				continue
+			}
+			coverparse.BestCoverBetween(&cover, c)
 			continue
 		}
 
 		if tags.CanRead(path) {
 			tags, err := tags.ReadTags(path)
 			if err != nil {
-				return "", nil, fmt.Errorf("read track: %w", err)
+				return coverparse.Cover{}, nil, fmt.Errorf("read track: %w", err)
 			}
 			pathTags = append(pathTags, PathTags{
 				Path: path,
@@ -355,7 +982,7 @@ func ReadReleaseDir(dirPath string) (string, []PathTags, error) {
 	}
 
 	if len(pathTags) == 0 {
-		return "", nil, ErrNoTracks
+		return coverparse.Cover{}, nil, ErrNoTracks
 	}
 
 	{
@@ -366,7 +993,7 @@ func ReadReleaseDir(dirPath string) (string, []PathTags, error) {
 			discDirs[filepath.Dir(pt.Path)] = struct{}{}
 		}
 		if len(discDirs) == 1 && filepath.Dir(pathTags[0].Path) != filepath.Clean(dirPath) {
-			return "", nil, fmt.Errorf("validate tree: %w", ErrNoTracks)
+			return coverparse.Cover{}, nil, fmt.Errorf("validate tree: %w", ErrNoTracks)
 		}
 	}
 
@@ -383,7 +1010,7 @@ func ReadReleaseDir(dirPath string) (string, []PathTags, error) {
 			}
 		}
 		if !haveNum && !havePath {
-			return "", nil, fmt.Errorf("no track numbers or numbers in filenames present: %w", ErrNotSortable)
+			return coverparse.Cover{}, nil, fmt.Errorf("no track numbers or numbers in filenames present: %w", ErrNotSortable)
 		}
 	}
 
@@ -396,7 +1023,7 @@ func ReadReleaseDir(dirPath string) (string, []PathTags, error) {
 		)
 	})
 
-	return string(cover), pathTags, nil
+	return cover, pathTags, nil
 }
 
 // DestDir generates the destination directory path for a release based on the given path format.
@@ -410,6 +1037,12 @@ func DestDir(pathFormat *pathformat.Format, release *musicbrainz.Release) (strin
 	return dir, nil
 }
 
+// inRoot reports whether path is below root.
+func inRoot(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsLocal(rel)
+}
+
 // FileSystemOperation defines operations that can be performed on files during the import/tagging process.
 // Implementations handle different ways to transfer files (move, copy, reflink) while maintaining consistent behaviours.
 type FileSystemOperation interface {
@@ -714,85 +1347,479 @@ func copyFile(src, dest string) (err error) {
 
 func processCover(
 	ctx context.Context, cfg *Config,
-	op FileSystemOperation, dc DirContext, release *musicbrainz.Release, destDir string, cover string,
+	op FileSystemOperation, dc DirContext, release *musicbrainz.Release, destDir string, cover coverparse.Cover,
 ) error {
+	names, err := cfg.CoverPolicy.names(release)
+	if err != nil {
+		return fmt.Errorf("cover names: %w", err)
+	}
 	coverPath := func(p string) string {
-		return filepath.Join(destDir, "cover"+filepath.Ext(p))
+		return filepath.Join(destDir, names[0]+filepath.Ext(p))
 	}
 
-	if op.CanModifyDest() && (cover == "" || cfg.UpgradeCover) {
-		skipFunc := func(resp *http.Response) bool {
-			if resp.ContentLength > 8388608 /* 8 MiB */ {
-				return true // too big to download
-			}
-			if cover == "" {
-				return false
-			}
-			info, err := os.Stat(cover)
-			if err != nil {
-				return false
-			}
-			return resp.ContentLength == info.Size()
+	var coverDest string
+	if op.CanModifyDest() && (cover.Path == "" || cfg.UpgradeCover) {
+		// try to avoid downloading a cover that's likely the one we have
+		skip := func(resp *http.Response) bool {
+			return cover.Path != "" && resp.ContentLength == cover.Size
 		}
-
-		coverTmp, err := tryDownloadMusicBrainzCover(ctx, &cfg.CoverArtArchiveClient, release, skipFunc)
+		coverTmp, err := tryDownloadMusicBrainzCover(ctx, &cfg.CoverArtArchiveClient, release, cfg.CoverPolicy, skip)
 		if err != nil {
-			return fmt.Errorf("maybe fetch better cover: %w", err)
+			// the tracks are already in place, so carry on with any cover we have
+			slog.WarnContext(ctx, "fetch cover", "err", err)
+			coverTmp = ""
+		}
+		if coverTmp != "" && cover.Path != "" {
+			better, err := isBetterCover(coverTmp, cover, cfg.CoverPolicy.Resize)
+			if err != nil {
+				return fmt.Errorf("compare covers: %w", err)
+			}
+			if !better {
+				_ = os.Remove(coverTmp)
+				coverTmp = ""
+			}
 		}
 		if coverTmp != "" {
-			if err := (Move{}).ProcessPath(dc, coverTmp, coverPath(coverTmp)); err != nil {
+			coverDest = coverPath(coverTmp)
+			if err := (Move{}).ProcessPath(dc, coverTmp, coverDest); err != nil {
 				return fmt.Errorf("move new cover to dest: %w", err)
 			}
-			return nil
 		}
 	}
 
 	// process any existing cover if we didn't fetch (or find) any from musicbrainz
-	if cover != "" {
-		if err := op.ProcessPath(dc, cover, coverPath(cover)); err != nil {
+	if coverDest == "" && cover.Path != "" {
+		coverDest = coverPath(cover.Path)
+		if err := op.ProcessPath(dc, cover.Path, coverDest); err != nil {
 			return fmt.Errorf("move file to dest: %w", err)
 		}
 	}
+	if coverDest == "" || !op.CanModifyDest() {
+		return nil
+	}
+
+	coverDest, err = cfg.CoverPolicy.convert(dc, coverDest)
+	if err != nil {
+		return fmt.Errorf("convert cover: %w", err)
+	}
+	for _, name := range names[1:] {
+		if err := (Copy{}).ProcessPath(dc, coverDest, filepath.Join(destDir, name+filepath.Ext(coverDest))); err != nil {
+			return fmt.Errorf("copy cover to %s: %w", name, err)
+		}
+	}
 	return nil
 }
 
-func tryDownloadMusicBrainzCover(ctx context.Context, caa *musicbrainz.CAAClient, release *musicbrainz.Release, skipFunc func(*http.Response) bool) (string, error) {
-	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
-	defer cancel()
+// CoverPolicy decides how covers are resized, converted, and named.
+type CoverPolicy struct {
+	// Resize scales covers down so that neither side is larger than this many pixels. If 0 they aren't scaled
+	Resize int
+
+	// Quality is the quality of JPEGs when a cover is scaled or converted. If 0 [imageutil.DefaultJPEGQuality] is used
+	Quality int
+
+	// ConvertJPEG converts covers in other formats, such as PNG, GIF, or BMP, to JPEG
+	ConvertJPEG bool
+
+	// MaxBytes is the largest cover to download. If the original is larger, a thumbnail is used. If 0, 8 MiB is used
+	MaxBytes int64
+
+	// Resolution is the preferred size of downloaded covers in pixels, such as 1200 or 500 to use a Cover Art Archive
+	// thumbnail. If 0, the original is preferred
+	Resolution int
+
+	// Names are the file names for the cover, without extension. The cover is written to the first one and copied
+	// to the rest. If none are set, the cover is named "cover"
+	Names []CoverName
+}
+
+func (p CoverPolicy) names(release *musicbrainz.Release) ([]string, error) {
+	if len(p.Names) == 0 {
+		return []string{"cover"}, nil
+	}
+	var names []string
+	for _, n := range p.Names {
+		name, err := n.Execute(release)
+		if err != nil {
+			return nil, err
+		}
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// convert scales and converts the cover at path, if the policy asks for it, and returns its new path. The extension
+// of the path changes if the format did.
+func (p CoverPolicy) convert(dc DirContext, path string) (string, error) {
+	if p.Resize == 0 && !p.ConvertJPEG {
+		return path, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read: %w", err)
+	}
+	config, mime, err := imageutil.DecodeConfig(data)
+	if err != nil {
+		return "", err
+	}
+
+	tooBig := p.Resize > 0 && max(config.Width, config.Height) > p.Resize
+	convert := p.ConvertJPEG && mime != "image/jpeg"
+	if !tooBig && !convert {
+		return path, nil
+	}
 
-	coverURL, err := caa.GetCoverURL(ctx, release)
+	toMIME := "image/png"
+	if p.ConvertJPEG || mime == "image/jpeg" {
+		toMIME = "image/jpeg"
+	}
+	data, err = imageutil.Reencode(data, p.Resize, toMIME, cmp.Or(p.Quality, imageutil.DefaultJPEGQuality))
 	if err != nil {
 		return "", err
 	}
-	if coverURL == "" {
+
+	newPath := path
+	if toMIME != mime {
+		newPath = strings.TrimSuffix(path, filepath.Ext(path)) + imageutil.Ext(toMIME)
+	}
+	if err := os.WriteFile(newPath, data, 0o644); err != nil {
+		return "", fmt.Errorf("write: %w", err)
+	}
+	if newPath != path {
+		if err := os.Remove(path); err != nil {
+			return "", fmt.Errorf("remove old: %w", err)
+		}
+		delete(dc.knownDestPaths, path)
+		dc.knownDestPaths[newPath] = struct{}{}
+	}
+	return newPath, nil
+}
+
+// CoverName is a template for the file name of a cover, with the same data and helper functions as path formats.
+type CoverName struct {
+	tmpl *texttemplate.Template
+}
+
+// ParseCoverName parses a template for the file name of a cover, such as "cover" or "{{ .Release.Title | safepath }}".
+func ParseCoverName(text string) (CoverName, error) {
+	tmpl, err := texttemplate.
+		New("cover name").
+		Funcs(pathformat.Funcs()).
+		Parse(text)
+	if err != nil {
+		return CoverName{}, fmt.Errorf("parse template: %w", err)
+	}
+
+	name := CoverName{tmpl: tmpl}
+
+	var release musicbrainz.Release
+	release.Title = "Title"
+	release.Media = []musicbrainz.Media{{Tracks: []musicbrainz.Track{{Title: "Title"}}}}
+	if _, err := name.Execute(&release); err != nil {
+		return CoverName{}, fmt.Errorf("validate: %w", err)
+	}
+	return name, nil
+}
+
+// Execute returns the file name for the release's cover. It's passed through safepath, so that a title with a slash
+// in it can't fail the import once the tracks are in place.
+func (n CoverName) Execute(release *musicbrainz.Release) (string, error) {
+	var sb strings.Builder
+	if err := n.tmpl.Execute(&sb, pathformat.NewData(release, 0, "", false)); err != nil {
+		return "", fmt.Errorf("execute: %w", err)
+	}
+	name := fileutil.SafePath(sb.String())
+	if name == "" || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid cover name %q", name)
+	}
+	return name, nil
+}
+
+// String returns the template text.
+func (n CoverName) String() string {
+	if n.tmpl == nil {
+		return ""
+	}
+	return n.tmpl.Root.String()
+}
+
+// extractEmbeddedCover writes the best front cover embedded in any of the tracks to a temporary file. Pictures without
+// a type are used if none are marked as the front cover. The path is empty if there aren't any.
+func extractEmbeddedCover(pathTags []PathTags) (coverparse.Cover, error) {
+	var best tags.Picture
+	var bestPixels int
+	better := func(pic tags.Picture, pixels int) bool {
+		if best.Data == nil {
+			return true
+		}
+		if (pic.Type == tags.PictureFrontCover) != (best.Type == tags.PictureFrontCover) {
+			return pic.Type == tags.PictureFrontCover
+		}
+		return cmp.Or(
+			cmp.Compare(pixels, bestPixels),
+			cmp.Compare(len(pic.Data), len(best.Data)),
+		) > 0
+	}
+
+	for _, pt := range pathTags {
+		if !tags.CanReadPictures(pt.Path) {
+			continue
+		}
+		pics, err := tags.ReadPictures(pt.Path)
+		if err != nil {
+			slog.Warn("reading embedded pictures", "file", filepath.Base(pt.Path), "err", err)
+			continue
+		}
+		for _, pic := range pics {
+			if pic.Type != tags.PictureFrontCover && pic.Type != tags.PictureOther {
+				continue
+			}
+			config, _, _ := imageutil.DecodeConfig(pic.Data)
+			if pixels := config.Width * config.Height; better(pic, pixels) {
+				best, bestPixels = pic, pixels
+			}
+		}
+	}
+	if best.Data == nil {
+		return coverparse.Cover{}, nil
+	}
+
+	ext := imageutil.Ext(http.DetectContentType(best.Data))
+	if ext == "" {
+		ext = imageutil.Ext(best.MIMEType)
+	}
+	if ext == "" {
+		return coverparse.Cover{}, nil // not an image we know
+	}
+
+	tmpf, err := os.CreateTemp("", ".wrtag-cover-tmp-*"+ext)
+	if err != nil {
+		return coverparse.Cover{}, fmt.Errorf("mktmp: %w", err)
+	}
+	defer tmpf.Close()
+
+	if _, err := tmpf.Write(best.Data); err != nil {
+		return coverparse.Cover{}, fmt.Errorf("write tmp: %w", err)
+	}
+	return coverparse.Stat(tmpf.Name())
+}
+
+// isBetterCover reports whether the downloaded cover at path has more pixels than the existing one, once it's been
+// scaled down to resize if that's set, since the existing one may have been already. If either couldn't be decoded,
+// it's better if it's a different size, since it's likely a new version.
+func isBetterCover(path string, existing coverparse.Cover, resize int) (bool, error) {
+	c, err := coverparse.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if c.Pixels() == 0 || existing.Pixels() == 0 {
+		return c.Size != existing.Size, nil
+	}
+	c.Width, c.Height = imageutil.ScaledSize(c.Width, c.Height, resize)
+	return c.Pixels() > existing.Pixels(), nil
+}
+
+const defaultMaxCoverBytes = 8 << 20
+
+// coverTimeout is how long each request to the Cover Art Archive can take.
+const coverTimeout = 30 * time.Second
+
+var errTooLarge = errors.New("too large")
+
+// tryDownloadMusicBrainzCover downloads the release's cover to a temporary file and returns its path, trying each
+// size of each cover until one is small enough and downloads successfully. If they were all too large, or skip
+// returned true for one, the path is empty, and if they all failed the errors are returned.
+func tryDownloadMusicBrainzCover(ctx context.Context, caa *musicbrainz.CAAClient, release *musicbrainz.Release, policy CoverPolicy, skip func(*http.Response) bool) (string, error) {
+	covers, err := func() ([]musicbrainz.CAAImage, error) {
+		ctx, cancel := context.WithTimeout(ctx, coverTimeout)
+		defer cancel()
+		return caa.GetCovers(ctx, release)
+	}()
+	if err != nil {
+		return "", fmt.Errorf("get covers: %w", err)
+	}
+
+	maxBytes := cmp.Or(policy.MaxBytes, defaultMaxCoverBytes)
+
+	var downloadErrs []error
+	var tooLarge bool
+	for _, url := range coverURLs(covers, policy.Resolution) {
+		tmp, err := func() (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, coverTimeout)
+			defer cancel()
+			return downloadTmp(ctx, caa.HTTPClient, url, maxBytes, skip)
+		}()
+		if errors.Is(err, errTooLarge) {
+			slog.Debug("cover too large to download", "url", url, "max", maxBytes)
+			tooLarge = true
+			continue
+		}
+		if err != nil {
+			slog.Warn("downloading cover, trying next", "url", url, "err", err)
+			downloadErrs = append(downloadErrs, err)
+			continue
+		}
+		if tmp == "" {
+			slog.Debug("skipping cover download", "url", url)
+		}
+		return tmp, nil
+	}
+	if tooLarge {
+		slog.Warn("all covers too large to download", "max", maxBytes)
 		return "", nil
 	}
+	return "", errors.Join(downloadErrs...)
+}
+
+// coverURLs lists the URLs to try for the covers in order. For each cover the smallest size that's at least the
+// preferred resolution comes first, then the smaller ones from largest to smallest, then the larger ones. The
+// original counts as the largest, and the 250px thumbnails are too small to use.
+func coverURLs(covers []musicbrainz.CAAImage, resolution int) []string {
+	const original = math.MaxInt
+	if resolution <= 0 {
+		resolution = original
+	}
+
+	var urls []string
+	for _, c := range covers {
+		bySize := map[int]string{original: c.URL}
+		for size, url := range c.Thumbnails {
+			if size >= 500 {
+				bySize[size] = url
+			}
+		}
+
+		sizes := slices.Sorted(maps.Keys(bySize))
+		first, _ := slices.BinarySearch(sizes, resolution)
+		urls = append(urls, bySize[sizes[first]])
+		for i := first - 1; i >= 0; i-- {
+			urls = append(urls, bySize[sizes[i]])
+		}
+		for i := first + 1; i < len(sizes); i++ {
+			urls = append(urls, bySize[sizes[i]])
+		}
+	}
+	return urls
+}
+
+// processScans downloads the extra images from the Cover Art Archive that match the configured types into the scans
+// directory. Images that were already downloaded are kept, unless covers are being upgraded.
+func processScans(
+	ctx context.Context, cfg *Config,
+	op FileSystemOperation, dc DirContext, release *musicbrainz.Release, destDir string,
+) error {
+	if len(cfg.ScanTypes) == 0 || !op.CanModifyDest() {
+		return nil
+	}
+
+	images, err := func() ([]musicbrainz.CAAImage, error) {
+		ctx, cancel := context.WithTimeout(ctx, coverTimeout)
+		defer cancel()
+		return cfg.CoverArtArchiveClient.GetImages(ctx, release)
+	}()
+	if err != nil {
+		// the tracks are already in place, so missing scans shouldn't fail the import
+		slog.WarnContext(ctx, "get scans", "err", err)
+		return nil
+	}
+
+	scansDir := filepath.Join(destDir, cmp.Or(cfg.ScansDir, "scans"))
+	maxBytes := cmp.Or(cfg.CoverPolicy.MaxBytes, defaultMaxCoverBytes)
+	for _, scan := range scanNames(images, cfg.ScanTypes) {
+		dest := filepath.Join(scansDir, scan.name)
+		if _, err := os.Stat(dest); err == nil && !cfg.UpgradeCover {
+			dc.knownDestPaths[dest] = struct{}{}
+			continue
+		}
+
+		tmp, err := func() (string, error) {
+			ctx, cancel := context.WithTimeout(ctx, coverTimeout)
+			defer cancel()
+			return downloadTmp(ctx, cfg.CoverArtArchiveClient.HTTPClient, scan.URL, maxBytes, nil)
+		}()
+		if err != nil {
+			slog.WarnContext(ctx, "download scan", "name", scan.name, "err", err)
+			continue
+		}
+		if err := (Move{}).ProcessPath(dc, tmp, dest); err != nil {
+			return fmt.Errorf("move %s to dest: %w", scan.name, err)
+		}
+	}
+	return nil
+}
+
+type scan struct {
+	musicbrainz.CAAImage
+	name string
+}
+
+// scanNames names the images that have any of the types after the first of those types and their Cover Art Archive
+// ID, like "booklet-32921095542.jpg", so that a name stays with the same image when others are added or reordered.
+func scanNames(images []musicbrainz.CAAImage, types []string) []scan {
+	var scans []scan
+	for _, img := range images {
+		i := slices.IndexFunc(img.Types, func(t string) bool {
+			return slices.ContainsFunc(types, func(want string) bool { return strings.EqualFold(t, want) })
+		})
+		if i < 0 || img.ID == "" {
+			continue
+		}
+		name := strings.ToLower(strings.ReplaceAll(img.Types[i], " ", "-"))
+		name = fileutil.SafePath(fmt.Sprintf("%s-%s%s", name, img.ID, strings.ToLower(path.Ext(img.URL))))
+		scans = append(scans, scan{CAAImage: img, name: name})
+	}
+	return scans
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, coverURL, nil)
+// downloadTmp downloads the url to a temporary file, and returns its path. If maxBytes isn't 0 and the response is
+// larger, nothing is kept and errTooLarge is returned. If skip isn't nil and returns true for the response, the
+// path is empty.
+func downloadTmp(ctx context.Context, client *http.Client, url string, maxBytes int64, skip func(*http.Response) bool) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return "", err
 	}
-	resp, err := caa.HTTPClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("request cover url: %w", err)
+		return "", fmt.Errorf("request url: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("non 2xx response: %d", resp.StatusCode)
+	}
+
 	// try to avoid downloading
-	if skipFunc(resp) {
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return "", errTooLarge
+	}
+	if skip != nil && skip(resp) {
 		return "", nil
 	}
 
-	ext := path.Ext(coverURL)
+	ext := path.Ext(url)
 	tmpf, err := os.CreateTemp("", ".wrtag-cover-tmp-*"+ext)
 	if err != nil {
 		return "", fmt.Errorf("mktmp: %w", err)
 	}
 	defer tmpf.Close()
 
-	if _, err := io.Copy(tmpf, resp.Body); err != nil {
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes+1) // the length isn't always known up front
+	}
+	n, err := io.Copy(tmpf, body)
+	if err != nil {
+		_ = os.Remove(tmpf.Name())
 		return "", fmt.Errorf("copy to tmp: %w", err)
 	}
+	if maxBytes > 0 && n > maxBytes {
+		_ = os.Remove(tmpf.Name())
+		return "", errTooLarge
+	}
 
 	return tmpf.Name(), nil
 }
@@ -815,6 +1842,40 @@ func dirSize(path string) (uint64, error) {
 	return size, err
 }
 
+// ignoredPaths returns the paths in dir and its subdirectories that ReadRelease leaves out because they're ignored,
+// along with dir's .wrtagignore file if it has one.
+func ignoredPaths(dir string, ignorePatterns []ignore.Pattern) ([]string, error) {
+	ignored, err := ignore.New(dir, ignorePatterns)
+	if err != nil {
+		return nil, fmt.Errorf("read ignore files: %w", err)
+	}
+
+	mainPaths, err := fileutil.GlobDir(dir, "*")
+	if err != nil {
+		return nil, fmt.Errorf("glob dir: %w", err)
+	}
+	discPaths, err := fileutil.GlobDir(dir, "*/*")
+	if err != nil {
+		return nil, fmt.Errorf("glob dir for discs: %w", err)
+	}
+
+	var paths []string
+	for _, path := range append(mainPaths, discPaths...) {
+		if filepath.Base(path) == ignore.FileName && filepath.Dir(path) == filepath.Clean(dir) {
+			paths = append(paths, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if d := filepath.Dir(path); ignored.Ignored(path, false) || (d != filepath.Clean(dir) && ignored.Ignored(d, true)) {
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
+
 func safeRemoveAll(src string, dryRun bool) error {
 	entries, err := os.ReadDir(src)
 	if err != nil {
@@ -851,9 +1912,9 @@ func safeRemoveAll(src string, dryRun bool) error {
 	return nil
 }
 
-func parseAnyTime(str string) time.Time {
-	t, _ := dateparse.ParseAny(str)
-	return t
+func parseDate(str string) musicbrainz.Date {
+	d, _ := musicbrainz.ParseDate(str)
+	return d
 }
 
 func logTagChanges(ctx context.Context, fileKey string, lvl slog.Level, before, after tags.Tags) {
@@ -865,25 +1926,293 @@ func logTagChanges(ctx context.Context, fileKey string, lvl slog.Level, before,
 	}
 }
 
-func extendQueryWithOriginFile(q *musicbrainz.ReleaseQuery, originFile *originfile.OriginFile) error {
-	if originFile == nil {
+// namedPathTags are a track's tags, with values from its name and its directory's name for tags it doesn't have.
+type namedPathTags struct {
+	tags.Tags
+	inferred map[string]string
+}
+
+func (t namedPathTags) Get(k string) string {
+	return cmp.Or(t.Tags.Get(k), t.inferred[k])
+}
+
+// nameFieldTags are the tags for the fields of name patterns.
+var nameFieldTags = map[string]string{
+	"artist": tags.Artist,
+	"album":  tags.Album,
+	"title":  tags.Title,
+	"year":   tags.Date,
+	"track":  tags.TrackNumber,
+	"disc":   tags.DiscNumber,
+	"media":  tags.MediaFormat,
+	"label":  tags.Label,
+	"catno":  tags.CatalogueNum,
+}
+
+// inferFromNames finds the values of tags that tracks don't have in their file names, and the name of their directory.
+func inferFromNames(cfg *Config, srcDir string, pathTags []PathTags) ([]namedPathTags, []InferredValue) {
+	named := make([]namedPathTags, 0, len(pathTags))
+	for _, pt := range pathTags {
+		named = append(named, namedPathTags{pt.Tags, map[string]string{}})
+	}
+
+	var inferred []InferredValue
+	infer := func(m nameparse.Match, track int) {
+		for _, field := range slices.Sorted(maps.Keys(m.Values)) {
+			k, ok := nameFieldTags[field]
+			if !ok {
+				continue
+			}
+			ks, v := []string{k}, m.Values[field]
+			if field == "artist" && track < 0 {
+				// also the track artist, unless the file names have one
+				ks = []string{tags.AlbumArtist, tags.Artist}
+			}
+
+			var used bool
+			for i := range named {
+				if track >= 0 && i != track {
+					continue
+				}
+				for _, k := range ks {
+					if named[i].Tags.Get(k) == "" {
+						named[i].inferred[k] = v
+						used = true
+					}
+				}
+			}
+			if !used {
+				continue
+			}
+
+			iv := InferredValue{Field: field, Value: v, Name: m.Name, Pattern: m.Pattern.String()}
+			if track >= 0 {
+				iv.Field = fmt.Sprintf("track %d %s", track+1, field)
+			}
+			slog.Debug("inferred from name", "field", iv.Field, "value", iv.Value, "name", iv.Name, "pattern", iv.Pattern)
+			inferred = append(inferred, iv)
+		}
+	}
+
+	if m := nameparse.MatchDir(slices.Concat(cfg.DirPatterns, nameparse.DirPatterns), srcDir); m != nil {
+		infer(*m, -1)
+	}
+
+	paths := make([]string, 0, len(pathTags))
+	for _, pt := range pathTags {
+		paths = append(paths, pt.Path)
+	}
+	for i, m := range nameparse.MatchFiles(slices.Concat(cfg.FilePatterns, nameparse.FilePatterns), paths) {
+		infer(m, i)
+	}
+
+	return named, inferred
+}
+
+// extendQueryWithSidecars fills the query with hints from sidecars, where the first sidecar with a hint wins. Their label,
+// catalogue number, barcode, media, and date replace the ones from tags, while artist and title only fill in missing ones.
+func extendQueryWithSidecars(q *musicbrainz.ReleaseQuery, sidecars []originfile.Sidecar) {
+	var h originfile.Hints
+	for _, s := range sidecars {
+		slog.Debug("using origin file", "format", s.Format, "file", s)
+
+		h.Artist = cmp.Or(h.Artist, s.Artist)
+		h.Title = cmp.Or(h.Title, s.Title)
+		h.Label = cmp.Or(h.Label, s.Label)
+		h.CatalogueNum = cmp.Or(h.CatalogueNum, s.CatalogueNum)
+		h.Barcode = cmp.Or(h.Barcode, s.Barcode)
+		h.Media = cmp.Or(h.Media, s.Media)
+		h.MBReleaseID = cmp.Or(h.MBReleaseID, s.MBReleaseID)
+		if h.Date.IsZero() {
+			h.Date = s.Date
+		}
+	}
+
+	q.Artist = cmp.Or(q.Artist, h.Artist)
+	q.Release = cmp.Or(q.Release, h.Title)
+	q.Label = cmp.Or(h.Label, q.Label)
+	q.CatalogueNum = cmp.Or(h.CatalogueNum, q.CatalogueNum)
+	q.Barcode = cmp.Or(h.Barcode, q.Barcode)
+	q.Format = cmp.Or(h.Media, q.Format)
+	q.MBReleaseID = cmp.Or(h.MBReleaseID, q.MBReleaseID)
+	if !h.Date.IsZero() {
+		q.Date = h.Date
+	}
+}
+
+// hasUsableTags reports whether the query has enough information for a text search to be meaningful.
+func hasUsableTags(q musicbrainz.ReleaseQuery) bool {
+	return q.MBReleaseID != "" || q.MBReleaseGroupID != "" || q.MBArtistID != "" || q.Release != "" || q.Artist != ""
+}
+
+// maxFingerprintCandidates is the number of releases found with fingerprints that are fetched and scored.
+const maxFingerprintCandidates = 3
+
+// searchFingerprints looks up the fingerprint of each track with AcoustID, and returns the best scoring
+// release out of the ones that the most tracks appear on. A nil release is returned if there were no matches.
+func searchFingerprints(ctx context.Context, cfg *Config, overrides *Overrides, pathTags []PathTags) (*musicbrainz.Release, error) {
+	counts := map[string]int{}
+	for _, pt := range pathTags {
+		fp, err := acoustid.Calculate(ctx, pt.Path)
+		if err != nil {
+			return nil, fmt.Errorf("calculate %q: %w", filepath.Base(pt.Path), err)
+		}
+		results, err := cfg.AcoustIDClient.Lookup(ctx, fp)
+		if err != nil {
+			return nil, fmt.Errorf("lookup %q: %w", filepath.Base(pt.Path), err)
+		}
+		for _, id := range acoustid.ReleaseIDs(results) {
+			counts[id]++
+		}
+	}
+
+	candidates := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(counts[b], counts[a]),
+			cmp.Compare(a, b),
+		)
+	})
+	candidates = candidates[:min(maxFingerprintCandidates, len(candidates))]
+
+	best, score, err := bestCandidate(ctx, cfg, overrides, candidates, pathTags)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "searched fingerprints", "candidates", candidates, "score", score)
+	return best, nil
+}
+
+// maxDiscIDCandidates is the number of releases with a matching disc that are fetched and scored.
+const maxDiscIDCandidates = 5
+
+// searchDiscID computes a MusicBrainz Disc ID from a rip log or CUE sheet in dir, if one exists, and returns the
+// best scoring release with a matching disc. A nil release is returned if there were no matches that score well
+// enough to be imported, so that the release can be searched for another way.
+func searchDiscID(ctx context.Context, cfg *Config, overrides *Overrides, dir string, pathTags []PathTags) (*musicbrainz.Release, error) {
+	toc, err := discid.Find(dir)
+	if err != nil {
+		return nil, fmt.Errorf("find toc: %w", err)
+	}
+	if toc == nil {
+		return nil, nil
+	}
+
+	id := toc.ID()
+	candidates, err := cfg.MusicBrainzClient.LookupDiscID(ctx, id, toc.String())
+	if err != nil {
+		return nil, fmt.Errorf("lookup %s: %w", id, err)
+	}
+	candidates = candidates[:min(maxDiscIDCandidates, len(candidates))]
+
+	best, score, err := bestCandidate(ctx, cfg, overrides, candidates, pathTags)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.DebugContext(ctx, "searched disc id", "disc_id", id, "candidates", candidates, "score", score)
+	if score < minScore {
+		return nil, nil
+	}
+	return best, nil
+}
+
+// bestCandidate fetches each release in ids and returns the one that best matches the local tracks. Releases with
+// a different number of tracks are never chosen.
+func bestCandidate(ctx context.Context, cfg *Config, overrides *Overrides, ids []string, pathTags []PathTags) (*musicbrainz.Release, float64, error) {
+	var best *musicbrainz.Release
+	var bestScore float64
+	for _, id := range ids {
+		release, err := cfg.MusicBrainzClient.GetRelease(ctx, id)
+		if err != nil {
+			return nil, 0, fmt.Errorf("get release by mbid %s: %w", id, err)
+		}
+		releaseTracks := musicbrainz.FlatTracks(release.Media)
+		if len(releaseTracks) != len(pathTags) {
+			continue
+		}
+		score, _ := tagmap.DiffRelease(cfg.TagWeights, tagOptions(cfg), release, releaseTracks, pathTags, writtenTags(cfg, overrides, release, pathTags))
+		if best == nil || score > bestScore {
+			best, bestScore = release, score
+		}
+	}
+	return best, bestScore, nil
+}
+
+// writtenTags applies the tag rules and any overridden tags to the values that a release is compared with, so that
+// tags written by them match on the next run. Errors from the rules are left to be reported when the tags are written.
+func writtenTags(cfg *Config, overrides *Overrides, release *musicbrainz.Release, pathTags []PathTags) func(int, *tags.Tags) {
+	if len(cfg.TagRules) == 0 && (overrides == nil || len(overrides.Tags) == 0) {
 		return nil
 	}
-	slog.Debug("using origin file", "file", originFile)
+	return func(i int, t *tags.Tags) {
+		var ext string
+		if i < len(pathTags) {
+			ext = strings.ToLower(filepath.Ext(pathTags[i].Path))
+		}
+		_ = tagmap.ApplyTagRules(t, cfg.TagRules, release, i, ext, cfg.Classical)
+		if overrides != nil {
+			for k, vs := range overrides.Tags {
+				t.Set(k, vs...)
+			}
+		}
+	}
+}
+
+func tagOptions(cfg *Config) tagmap.Options {
+	return tagmap.Options{
+		Relationships: cfg.Relationships,
+		Classical:     cfg.Classical,
+		FeatMatch:     cfg.FeatMatch,
+		FeatStyle:     cfg.FeatStyle,
+	}
+}
 
-	if originFile.RecordLabel != "" {
-		q.Label = originFile.RecordLabel
+// removedTags returns the existing tags that the retention policy will remove from any of the files, ignoring the ones
+// that wrtag writes anyway.
+func removedTags(
+	cfg *Config, release *musicbrainz.Release, labelInfo musicbrainz.LabelInfo, genres []musicbrainz.Genre,
+	releaseTracks []musicbrainz.Track, pathTags []PathTags,
+	opts tagmap.Options,
+) []string {
+	var removed []string
+	for i := range min(len(releaseTracks), len(pathTags)) {
+		destTags := tagmap.ReleaseTags(release, labelInfo, genres, i, &releaseTracks[i], opts)
+		for _, r := range cfg.TagRules {
+			destTags.Set(r.Key)
+		}
+		cfg.TagMerge.Merge(pathTags[i].Tags, &destTags)
+		removed = append(removed, cfg.TagRetention.Removed(pathTags[i].Tags, destTags)...)
 	}
-	if originFile.CatalogueNumber != "" {
-		q.CatalogueNum = originFile.CatalogueNumber
+	slices.Sort(removed)
+	return slices.Compact(removed)
+}
+
+// localiseRelease names the release's artists by the preferred locales, and takes its titles from a pseudo-release
+// in the preferred script if the release isn't already written in it. The release is localised before it's compared
+// to the local tags, so that a directory that was already imported with the same preferences still matches exactly.
+func localiseRelease(ctx context.Context, cfg *Config, release *musicbrainz.Release) error {
+	musicbrainz.LocaliseArtists(release, cfg.Locales)
+
+	if cfg.LocaleScript == "" || strings.EqualFold(release.TextRepresentation.Script, cfg.LocaleScript) {
+		return nil
 	}
-	if originFile.Media != "" {
-		media := originFile.Media
-		media = strings.ReplaceAll(media, "WEB", "Digital Media")
-		q.Format = media
+	ids, err := cfg.MusicBrainzClient.GetPseudoReleaseIDs(ctx, release.ID)
+	if err != nil {
+		return fmt.Errorf("get pseudo-releases: %w", err)
 	}
-	if originFile.EditionYear > 0 {
-		q.Date = time.Date(originFile.EditionYear, 0, 0, 0, 0, 0, 0, time.UTC)
+	for _, id := range ids {
+		pseudo, err := cfg.MusicBrainzClient.GetRelease(ctx, id)
+		if err != nil {
+			return fmt.Errorf("get pseudo-release by mbid %s: %w", id, err)
+		}
+		if !strings.EqualFold(pseudo.TextRepresentation.Script, cfg.LocaleScript) {
+			continue
+		}
+		if musicbrainz.ApplyPseudoRelease(release, pseudo) {
+			slog.DebugContext(ctx, "using titles from pseudo-release", "mbid", id, "script", pseudo.TextRepresentation.Script)
+			return nil
+		}
 	}
 	return nil
 }